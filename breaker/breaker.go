@@ -0,0 +1,233 @@
+package breaker
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/yashap/concurrency/schedule"
+)
+
+// ErrOpen is returned instead of calling something whose breaker is open
+var ErrOpen = errors.New("circuit breaker is open")
+
+// State is the state of a Breaker
+type State int
+
+const (
+	// Closed means calls go through as normal, while the breaker counts failures
+	Closed State = iota
+	// Open means calls fail fast with ErrOpen, until the cool-down is over
+	Open
+	// HalfOpen means a few probe calls go through, to see if things are working again
+	HalfOpen
+)
+
+func (s State) String() string {
+	switch s {
+	case Closed:
+		return "closed"
+	case Open:
+		return "open"
+	case HalfOpen:
+		return "half-open"
+	}
+	return "unknown"
+}
+
+// Options configures a Breaker. Zero values mean the defaults, or, for the thresholds,
+// that the threshold isn't used
+type Options struct {
+	ConsecutiveFailures int           // open after this many failures in a row
+	FailureRate         float64       // open once this fraction of the last Window calls failed, e.g. 0.5
+	Window              int           // how many recent calls FailureRate looks at, 20 if not set
+	CoolDown            time.Duration // how long to stay open before letting probes through
+	Probes              int           // how many probes to let through when half-open, 1 if not set
+
+	// IsFailure says whether an error counts as a failure. By default any error does,
+	// except context.Canceled, since that means the caller gave up, e.g. a losing replica
+	IsFailure func(err error) bool
+
+	// OnStateChange is called after the breaker changes state, e.g. to log it
+	OnStateChange func(from, to State)
+}
+
+// Breaker is a circuit breaker. It stops calling something that keeps failing, e.g.
+// a search backend or rss feed host, so callers fail fast rather than waiting on it,
+// and it gets a chance to recover. After a cool-down, it lets a few probe calls through.
+// If they all succeed, it closes again, if any fail, it opens again
+type Breaker struct {
+	clock   schedule.Clock
+	options Options
+
+	mu          sync.Mutex
+	state       State
+	generation  int // bumped on every state change
+	openedAt    time.Time
+	consecutive int    // failures in a row
+	outcomes    []bool // a ring buffer of recent calls, true for failures
+	next        int    // where the next outcome goes in outcomes
+	failures    int    // how many of outcomes are failures
+	probes      int    // probes let through since going half-open
+	succeeded   int    // probes that succeeded since going half-open
+	changes     []func()
+}
+
+// New creates a closed Breaker. The cool-down is measured with clock
+func New(clock schedule.Clock, options Options) *Breaker {
+	if options.Window <= 0 {
+		options.Window = 20
+	}
+	if options.Probes <= 0 {
+		options.Probes = 1
+	}
+	if options.IsFailure == nil {
+		options.IsFailure = func(err error) bool {
+			return err != nil && !errors.Is(err, context.Canceled)
+		}
+	}
+	return &Breaker{clock: clock, options: options}
+}
+
+// State returns the breaker's current state
+func (b *Breaker) State() State {
+	b.lock()
+	defer b.unlock()
+	b.coolDown()
+	return b.state
+}
+
+// Allow asks to make a call. If the breaker is open, or half-open with all its probes
+// already let through, it returns ErrOpen. Otherwise, make the call, then pass its
+// error, or nil, to done, so the breaker can count it
+func (b *Breaker) Allow() (done func(err error), err error) {
+	b.lock()
+	defer b.unlock()
+	b.coolDown()
+	switch b.state {
+	case Open:
+		return nil, ErrOpen
+	case HalfOpen:
+		if b.probes >= b.options.Probes {
+			return nil, ErrOpen
+		}
+		b.probes++
+	}
+
+	// Calls let through before a state change are ignored after it, they'd count
+	// towards the wrong state
+	generation := b.generation
+	var once sync.Once
+	return func(err error) {
+		once.Do(func() {
+			b.lock()
+			defer b.unlock()
+			if b.generation != generation {
+				return
+			}
+			b.record(err)
+		})
+	}, nil
+}
+
+// Do calls fn if the breaker allows it, and counts its error, otherwise it returns
+// ErrOpen
+func (b *Breaker) Do(fn func() error) error {
+	done, err := b.Allow()
+	if err != nil {
+		return err
+	}
+	err = fn()
+	done(err)
+	return err
+}
+
+// record counts a call's outcome. Must be called with mu held
+func (b *Breaker) record(err error) {
+	if err != nil && !b.options.IsFailure(err) {
+		if b.state == HalfOpen {
+			b.probes-- // it didn't tell us anything, let another probe through
+		}
+		return
+	}
+	failed := err != nil
+
+	if b.state == HalfOpen {
+		if failed {
+			b.setState(Open)
+			return
+		}
+		b.succeeded++
+		if b.succeeded >= b.options.Probes {
+			b.setState(Closed)
+		}
+		return
+	}
+
+	if failed {
+		b.consecutive++
+	} else {
+		b.consecutive = 0
+	}
+	if len(b.outcomes) < b.options.Window {
+		b.outcomes = append(b.outcomes, failed)
+	} else {
+		if b.outcomes[b.next] {
+			b.failures--
+		}
+		b.outcomes[b.next] = failed
+		b.next = (b.next + 1) % b.options.Window
+	}
+	if failed {
+		b.failures++
+	}
+
+	tooManyInARow := b.options.ConsecutiveFailures > 0 && b.consecutive >= b.options.ConsecutiveFailures
+	// The rate only counts once the window is full, so a couple of early failures don't open it
+	tooManyRecently := b.options.FailureRate > 0 && len(b.outcomes) == b.options.Window &&
+		float64(b.failures)/float64(b.options.Window) >= b.options.FailureRate
+	if tooManyInARow || tooManyRecently {
+		b.setState(Open)
+	}
+}
+
+// coolDown moves an open breaker to half-open once the cool-down is over. Must be
+// called with mu held
+func (b *Breaker) coolDown() {
+	if b.state == Open && b.clock.Now().Sub(b.openedAt) >= b.options.CoolDown {
+		b.setState(HalfOpen)
+	}
+}
+
+// setState changes state, starting the new state's counts from scratch. Must be
+// called with mu held
+func (b *Breaker) setState(to State) {
+	from := b.state
+	b.state = to
+	b.generation++
+	b.consecutive, b.failures, b.next = 0, 0, 0
+	b.outcomes = b.outcomes[:0]
+	b.probes, b.succeeded = 0, 0
+	if to == Open {
+		b.openedAt = b.clock.Now()
+	}
+	if hook := b.options.OnStateChange; hook != nil && from != to {
+		b.changes = append(b.changes, func() { hook(from, to) })
+	}
+}
+
+func (b *Breaker) lock() {
+	b.mu.Lock()
+}
+
+// unlock unlocks, then runs any state change hooks, so they can call back into the
+// breaker without deadlocking
+func (b *Breaker) unlock() {
+	changes := b.changes
+	b.changes = nil
+	b.mu.Unlock()
+	for _, change := range changes {
+		change()
+	}
+}
@@ -0,0 +1,134 @@
+package breaker
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/yashap/concurrency/schedule"
+)
+
+var errBoom = errors.New("boom")
+
+func fail() error    { return errBoom }
+func succeed() error { return nil }
+
+func TestConsecutiveFailures(t *testing.T) {
+	clock := schedule.NewFakeClock(time.Now())
+	b := New(clock, Options{ConsecutiveFailures: 3, CoolDown: time.Minute})
+
+	b.Do(fail)
+	b.Do(fail)
+	b.Do(succeed) // breaks the run
+	b.Do(fail)
+	b.Do(fail)
+	if b.State() != Closed {
+		t.Errorf("Expected the breaker to be closed, was %v", b.State())
+	}
+	b.Do(fail)
+	if b.State() != Open {
+		t.Errorf("Expected the breaker to be open after 3 failures in a row, was %v", b.State())
+	}
+
+	called := false
+	if err := b.Do(func() error { called = true; return nil }); !errors.Is(err, ErrOpen) || called {
+		t.Errorf("Expected an open breaker to fail fast with %v, but got %v, and called was %v", ErrOpen, err, called)
+	}
+}
+
+func TestFailureRate(t *testing.T) {
+	clock := schedule.NewFakeClock(time.Now())
+	b := New(clock, Options{FailureRate: 0.5, Window: 4, CoolDown: time.Minute})
+
+	// Not enough calls to judge yet
+	b.Do(fail)
+	b.Do(fail)
+	b.Do(succeed)
+	if b.State() != Closed {
+		t.Errorf("Expected the breaker to be closed until the window is full, was %v", b.State())
+	}
+	b.Do(succeed)
+	if b.State() != Open {
+		t.Errorf("Expected the breaker to be open with half the calls failing, was %v", b.State())
+	}
+}
+
+func TestHalfOpen(t *testing.T) {
+	clock := schedule.NewFakeClock(time.Now())
+	b := New(clock, Options{ConsecutiveFailures: 1, CoolDown: time.Minute, Probes: 2})
+	b.Do(fail)
+
+	clock.Advance(time.Minute)
+	if b.State() != HalfOpen {
+		t.Errorf("Expected the breaker to be half-open after the cool-down, was %v", b.State())
+	}
+
+	// Only so many probes go through at once
+	done1, err1 := b.Allow()
+	done2, err2 := b.Allow()
+	if err1 != nil || err2 != nil {
+		t.Fatalf("Expected 2 probes to be allowed, but got %v and %v", err1, err2)
+	}
+	if _, err := b.Allow(); !errors.Is(err, ErrOpen) {
+		t.Errorf("Expected a third probe to fail with %v, but got %v", ErrOpen, err)
+	}
+
+	// They all have to succeed to close it
+	done1(nil)
+	if b.State() != HalfOpen {
+		t.Errorf("Expected the breaker to stay half-open until every probe succeeds, was %v", b.State())
+	}
+	done2(nil)
+	if b.State() != Closed {
+		t.Errorf("Expected the breaker to be closed, was %v", b.State())
+	}
+}
+
+func TestHalfOpenProbeFails(t *testing.T) {
+	clock := schedule.NewFakeClock(time.Now())
+	b := New(clock, Options{ConsecutiveFailures: 1, CoolDown: time.Minute})
+	b.Do(fail)
+	clock.Advance(time.Minute)
+
+	b.Do(fail)
+	if b.State() != Open {
+		t.Errorf("Expected a failed probe to open the breaker again, was %v", b.State())
+	}
+	clock.Advance(time.Minute - time.Millisecond)
+	if b.State() != Open {
+		t.Errorf("Expected the cool-down to start over, was %v", b.State())
+	}
+}
+
+func TestCancelledIsNotAFailure(t *testing.T) {
+	clock := schedule.NewFakeClock(time.Now())
+	b := New(clock, Options{ConsecutiveFailures: 1, CoolDown: time.Minute})
+	b.Do(func() error { return context.Canceled })
+	if b.State() != Closed {
+		t.Errorf("Expected a cancelled call not to count as a failure, was %v", b.State())
+	}
+}
+
+func TestOnStateChange(t *testing.T) {
+	clock := schedule.NewFakeClock(time.Now())
+	var changes []string
+	var b *Breaker
+	b = New(clock, Options{
+		ConsecutiveFailures: 1,
+		CoolDown:            time.Minute,
+		OnStateChange: func(from, to State) {
+			// Calling back into the breaker from the hook is fine
+			changes = append(changes, from.String()+" -> "+to.String()+" ("+b.State().String()+")")
+		},
+	})
+	b.Do(fail)
+	clock.Advance(time.Minute)
+	b.Do(succeed)
+
+	expected := []string{"closed -> open (open)", "open -> half-open (half-open)", "half-open -> closed (closed)"}
+	if !reflect.DeepEqual(changes, expected) {
+		t.Errorf("Expected %v, got %v", expected, changes)
+	}
+}
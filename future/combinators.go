@@ -0,0 +1,127 @@
+package future
+
+import (
+	"errors"
+)
+
+// ErrNoFutures is the error combinators fail with when they're given no Futures, so
+// there's no first one to wait for
+var ErrNoFutures = errors.New("no futures to wait for")
+
+// Result is a Future's value or error, see AllSettled
+type Result[T any] struct {
+	Value T
+	Err   error
+}
+
+type settled[T any] struct {
+	index int
+	Result[T]
+}
+
+// settle sends each Future's result to a channel, as they're ready. The channel has
+// room for all of them, so the goroutines never block, even if the caller stops
+// reading early
+func settle[T any](fs []*Future[T]) <-chan settled[T] {
+	c := make(chan settled[T], len(fs))
+	for i, f := range fs {
+		go func(i int, f *Future[T]) {
+			<-f.done
+			c <- settled[T]{i, Result[T]{f.value, f.err}}
+		}(i, f)
+	}
+	return c
+}
+
+// cancelAll cancels every Future in fs
+func cancelAll[T any](fs []*Future[T]) func() {
+	return func() {
+		for _, f := range fs {
+			f.Cancel()
+		}
+	}
+}
+
+// All is ready once all of fs are, with their values in the same order. If any of
+// them fail, it fails with the first error, and cancels the rest
+func All[T any](fs ...*Future[T]) *Future[[]T] {
+	cancel := cancelAll(fs)
+	all := newFuture[[]T](cancel)
+	go func() {
+		values := make([]T, len(fs))
+		results := settle(fs)
+		for range fs {
+			r := <-results
+			if r.Err != nil {
+				cancel()
+				all.resolve(nil, r.Err)
+				return
+			}
+			values[r.index] = r.Value
+		}
+		all.resolve(values, nil)
+	}()
+	return all
+}
+
+// Any is ready once the first of fs succeeds, with its value, and cancels the rest.
+// If they all fail, it fails with all of their errors
+func Any[T any](fs ...*Future[T]) *Future[T] {
+	cancel := cancelAll(fs)
+	first := newFuture[T](cancel)
+	go func() {
+		if len(fs) == 0 {
+			var zero T
+			first.resolve(zero, ErrNoFutures)
+			return
+		}
+		errs := make([]error, len(fs))
+		results := settle(fs)
+		for range fs {
+			r := <-results
+			if r.Err == nil {
+				cancel()
+				first.resolve(r.Value, nil)
+				return
+			}
+			errs[r.index] = r.Err
+		}
+		var zero T
+		first.resolve(zero, errors.Join(errs...))
+	}()
+	return first
+}
+
+// Race is ready once the first of fs is, with its value or error, whichever it is,
+// and cancels the rest
+func Race[T any](fs ...*Future[T]) *Future[T] {
+	cancel := cancelAll(fs)
+	first := newFuture[T](cancel)
+	go func() {
+		if len(fs) == 0 {
+			var zero T
+			first.resolve(zero, ErrNoFutures)
+			return
+		}
+		r := <-settle(fs)
+		cancel()
+		first.resolve(r.Value, r.Err)
+	}()
+	return first
+}
+
+// AllSettled is ready once all of fs are, with each one's value or error, in the same
+// order. It never fails, and never cancels any of them
+func AllSettled[T any](fs ...*Future[T]) *Future[[]Result[T]] {
+	all := newFuture[[]Result[T]](cancelAll(fs))
+	go func() {
+		results := make([]Result[T], len(fs))
+		settled := settle(fs)
+		for range fs {
+			r := <-settled
+			results[r.index] = r.Result
+		}
+		all.resolve(results, nil)
+	}()
+	return all
+}
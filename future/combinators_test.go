@@ -0,0 +1,90 @@
+package future
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestAll(t *testing.T) {
+	ctx := context.Background()
+	release := make(chan struct{})
+	close(release)
+	all := All(Go(ctx, value(1, release)), Go(ctx, value(2, release)), Go(ctx, value(3, release)))
+	if v, err := all.Await(ctx); err != nil || !reflect.DeepEqual(v, []int{1, 2, 3}) {
+		t.Errorf("Expected [1 2 3], but got %v and error %v", v, err)
+	}
+
+	// One failure cancels the rest
+	boom := errors.New("boom")
+	slow := Go(ctx, value(1, make(chan struct{})))
+	if _, err := All(slow, Go(ctx, failure[int](boom))).Await(ctx); !errors.Is(err, boom) {
+		t.Errorf("Expected %v, but got %v", boom, err)
+	}
+	if _, err := slow.Await(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected the slow future to be cancelled, but got %v", err)
+	}
+}
+
+func TestAny(t *testing.T) {
+	ctx := context.Background()
+	release := make(chan struct{})
+	close(release)
+	slow := Go(ctx, value(1, make(chan struct{})))
+	boom := errors.New("boom")
+	first := Any(Go(ctx, failure[int](boom)), slow, Go(ctx, value(2, release)))
+	if v, err := first.Await(ctx); err != nil || v != 2 {
+		t.Errorf("Expected the first success 2, but got %d and error %v", v, err)
+	}
+	if _, err := slow.Await(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected the slow future to be cancelled, but got %v", err)
+	}
+
+	// If they all fail, we get all the errors
+	bang := errors.New("bang")
+	_, err := Any(Go(ctx, failure[int](boom)), Go(ctx, failure[int](bang))).Await(ctx)
+	if !errors.Is(err, boom) || !errors.Is(err, bang) {
+		t.Errorf("Expected both %v and %v, but got %v", boom, bang, err)
+	}
+}
+
+func TestRace(t *testing.T) {
+	ctx := context.Background()
+	slow := Go(ctx, value(1, make(chan struct{})))
+	boom := errors.New("boom")
+	if _, err := Race(slow, Go(ctx, failure[int](boom))).Await(ctx); !errors.Is(err, boom) {
+		t.Errorf("Expected the first to finish to win, even though it failed, but got %v", err)
+	}
+	if _, err := slow.Await(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected the slow future to be cancelled, but got %v", err)
+	}
+}
+
+func TestAllSettled(t *testing.T) {
+	ctx := context.Background()
+	release := make(chan struct{})
+	close(release)
+	boom := errors.New("boom")
+	settled, err := AllSettled(Go(ctx, value(1, release)), Go(ctx, failure[int](boom))).Await(ctx)
+	if err != nil {
+		t.Errorf("Expected no error, but got %v", err)
+	}
+	expected := []Result[int]{{Value: 1}, {Err: boom}}
+	if !reflect.DeepEqual(settled, expected) {
+		t.Errorf("Expected %v, got %v", expected, settled)
+	}
+}
+
+func TestNoFutures(t *testing.T) {
+	ctx := context.Background()
+	if _, err := Any[int]().Await(ctx); !errors.Is(err, ErrNoFutures) {
+		t.Errorf("Expected %v, but got %v", ErrNoFutures, err)
+	}
+	if _, err := Race[int]().Await(ctx); !errors.Is(err, ErrNoFutures) {
+		t.Errorf("Expected %v, but got %v", ErrNoFutures, err)
+	}
+	if v, err := All[int]().Await(ctx); err != nil || len(v) != 0 {
+		t.Errorf("Expected no values, but got %v and error %v", v, err)
+	}
+}
@@ -0,0 +1,137 @@
+package future
+
+import (
+	"context"
+	"sync"
+)
+
+// Future is a value that will be ready at some point, e.g. the result of a function
+// running in another goroutine. Rather than every caller making a channel, starting
+// a goroutine, and reading one value from it, they can start it with Go, and Await
+// the result
+type Future[T any] struct {
+	done   chan struct{}
+	value  T
+	err    error
+	cancel func()
+}
+
+func newFuture[T any](cancel func()) *Future[T] {
+	return &Future[T]{done: make(chan struct{}), cancel: cancel}
+}
+
+// resolve sets the result. It must only be called once
+func (f *Future[T]) resolve(value T, err error) {
+	f.value, f.err = value, err
+	close(f.done)
+}
+
+// Go runs fn in a new goroutine, and returns a Future for its result. fn's ctx is
+// cancelled by Cancel, or once fn has returned
+func Go[T any](ctx context.Context, fn func(ctx context.Context) (T, error)) *Future[T] {
+	ctx, cancel := context.WithCancel(ctx)
+	f := newFuture[T](cancel)
+	go func() {
+		defer cancel()
+		f.resolve(fn(ctx))
+	}()
+	return f
+}
+
+// Done is closed once the result is ready
+func (f *Future[T]) Done() <-chan struct{} {
+	return f.done
+}
+
+// Await blocks until the result is ready, then returns it. If ctx is done first, it
+// returns ctx.Err(), but whatever the Future is waiting on keeps going, see Cancel. A
+// result that's already ready is always returned, even if ctx is done too
+func (f *Future[T]) Await(ctx context.Context) (T, error) {
+	// If both are ready, select would pick one at random, so check for the result first
+	select {
+	case <-f.done:
+		return f.value, f.err
+	default:
+	}
+	select {
+	case <-f.done:
+		return f.value, f.err
+	case <-ctx.Done():
+		var zero T
+		return zero, ctx.Err()
+	}
+}
+
+// Cancel cancels the ctx of whatever the Future is waiting on, if it has one, e.g.
+// the function passed to Go. It doesn't wait for it to return
+func (f *Future[T]) Cancel() {
+	if f.cancel != nil {
+		f.cancel()
+	}
+}
+
+// Promise is the other end of a Future: code that isn't started with Go can hand out
+// a Future, and Resolve it once the result is ready, e.g. a worker pool
+type Promise[T any] struct {
+	future *Future[T]
+	once   sync.Once
+}
+
+// NewPromise creates a Promise, with a Future that's ready once it's resolved
+func NewPromise[T any]() *Promise[T] {
+	return &Promise[T]{future: newFuture[T](nil)}
+}
+
+// Future returns the Future that's ready once p is resolved
+func (p *Promise[T]) Future() *Future[T] {
+	return p.future
+}
+
+// Resolve sets the Future's result. Only the first call counts, it returns false for
+// any after that
+func (p *Promise[T]) Resolve(value T, err error) bool {
+	resolved := false
+	p.once.Do(func() {
+		p.future.resolve(value, err)
+		resolved = true
+	})
+	return resolved
+}
+
+// Then runs next with f's value, once it's ready, and returns a Future for next's
+// result. If f fails, next isn't run, and the returned Future fails with f's error.
+// Cancelling the returned Future cancels f too
+func Then[A, B any](ctx context.Context, f *Future[A], next func(ctx context.Context, v A) (B, error)) *Future[B] {
+	ctx, cancel := context.WithCancel(ctx)
+	then := newFuture[B](func() {
+		cancel()
+		f.Cancel()
+	})
+	go func() {
+		defer cancel()
+		a, err := f.Await(ctx)
+		if err != nil {
+			var zero B
+			then.resolve(zero, err)
+			return
+		}
+		then.resolve(next(ctx, a))
+	}()
+	return then
+}
+
+// Map is like Then, for transforming a value, when there's nothing to wait on, or
+// fail
+func Map[A, B any](f *Future[A], fn func(v A) B) *Future[B] {
+	mapped := newFuture[B](f.Cancel)
+	go func() {
+		<-f.done
+		if f.err != nil {
+			var zero B
+			mapped.resolve(zero, f.err)
+			return
+		}
+		mapped.resolve(fn(f.value), nil)
+	}()
+	return mapped
+}
@@ -0,0 +1,131 @@
+package future
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+)
+
+// value returns a function that waits to be released, then returns v
+func value[T any](v T, release <-chan struct{}) func(ctx context.Context) (T, error) {
+	return func(ctx context.Context) (T, error) {
+		select {
+		case <-release:
+			return v, nil
+		case <-ctx.Done():
+			var zero T
+			return zero, ctx.Err()
+		}
+	}
+}
+
+func failure[T any](err error) func(ctx context.Context) (T, error) {
+	return func(context.Context) (T, error) {
+		var zero T
+		return zero, err
+	}
+}
+
+func TestGo(t *testing.T) {
+	ctx := context.Background()
+	release := make(chan struct{})
+	f := Go(ctx, value(42, release))
+
+	select {
+	case <-f.Done():
+		t.Errorf("Expected the future not to be ready yet")
+	default:
+	}
+	close(release)
+	if v, err := f.Await(ctx); err != nil || v != 42 {
+		t.Errorf("Expected 42, but got %d and error %v", v, err)
+	}
+}
+
+func TestAwaitCancelled(t *testing.T) {
+	f := Go(context.Background(), value(42, make(chan struct{})))
+	defer f.Cancel()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := f.Await(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected %v, but got %v", context.Canceled, err)
+	}
+}
+
+func TestAwaitReadyAndCancelled(t *testing.T) {
+	release := make(chan struct{})
+	close(release)
+	f := Go(context.Background(), value(42, release))
+	<-f.Done()
+
+	// Both the result and ctx.Done() are ready, the result should always win
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	for i := 0; i < 100; i++ {
+		if v, err := f.Await(ctx); err != nil || v != 42 {
+			t.Fatalf("Expected 42, but got %d and error %v", v, err)
+		}
+	}
+}
+
+func TestCancel(t *testing.T) {
+	ctx := context.Background()
+	f := Go(ctx, value(42, make(chan struct{})))
+	f.Cancel()
+	if _, err := f.Await(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected %v, but got %v", context.Canceled, err)
+	}
+}
+
+func TestPromise(t *testing.T) {
+	p := NewPromise[string]()
+	if !p.Resolve("first", nil) {
+		t.Errorf("Expected the first Resolve to count")
+	}
+	if p.Resolve("second", nil) {
+		t.Errorf("Expected the second Resolve not to count")
+	}
+	if v, err := p.Future().Await(context.Background()); err != nil || v != "first" {
+		t.Errorf("Expected %q, but got %q and error %v", "first", v, err)
+	}
+}
+
+func TestThenAndMap(t *testing.T) {
+	ctx := context.Background()
+	release := make(chan struct{})
+	close(release)
+	f := Go(ctx, value(21, release))
+	doubled := Then(ctx, f, func(_ context.Context, v int) (int, error) { return v * 2, nil })
+	formatted := Map(doubled, strconv.Itoa)
+	if v, err := formatted.Await(ctx); err != nil || v != "42" {
+		t.Errorf("Expected %q, but got %q and error %v", "42", v, err)
+	}
+
+	// Errors skip over Then and Map
+	boom := errors.New("boom")
+	called := false
+	failed := Map(Then(ctx, Go(ctx, failure[int](boom)), func(_ context.Context, v int) (int, error) {
+		called = true
+		return v, nil
+	}), strconv.Itoa)
+	if _, err := failed.Await(ctx); !errors.Is(err, boom) {
+		t.Errorf("Expected %v, but got %v", boom, err)
+	}
+	if called {
+		t.Errorf("Expected Then not to run after a failure")
+	}
+}
+
+func TestThenCancel(t *testing.T) {
+	ctx := context.Background()
+	f := Go(ctx, value(1, make(chan struct{})))
+	then := Then(ctx, f, func(_ context.Context, v int) (int, error) { return v, nil })
+	then.Cancel()
+	if _, err := f.Await(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected cancelling Then to cancel the future it waits on, but got %v", err)
+	}
+	if _, err := then.Await(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected %v, but got %v", context.Canceled, err)
+	}
+}
@@ -0,0 +1,142 @@
+package multiplex
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// Overflow is what FanInBuffered does with a new value when its buffer is full
+type Overflow int
+
+const (
+	// Block makes producers wait until there's room in the buffer, like FanIn
+	Block Overflow = iota
+	// DropNewest throws away the new value, keeping everything already buffered
+	DropNewest
+	// DropOldest throws away the oldest buffered value, to make room for the new one
+	DropOldest
+	// Sample keeps 1 in every BufferConfig.SampleEvery values that overflow (making
+	// room by dropping the oldest), and throws away the rest
+	Sample
+)
+
+// BufferConfig configures FanInBuffered
+type BufferConfig struct {
+	Size        int      // max number of values buffered, at least 1
+	Overflow    Overflow // what to do when the buffer is full
+	SampleEvery int      // with the Sample policy, keep 1 in every SampleEvery overflowing values
+}
+
+// Buffered is the result of FanInBuffered
+type Buffered[T any] struct {
+	output  <-chan T
+	dropped []atomic.Int64
+}
+
+// Output is the merged channel, closed once all inputs are closed and the buffer is
+// drained, or the context passed to FanInBuffered is cancelled
+func (b *Buffered[T]) Output() <-chan T {
+	return b.output
+}
+
+// Dropped returns how many values from each input have been thrown away because the
+// buffer was full, in the same order the inputs were passed to FanInBuffered
+func (b *Buffered[T]) Dropped() []int64 {
+	dropped := make([]int64, len(b.dropped))
+	for i := range b.dropped {
+		dropped[i] = b.dropped[i].Load()
+	}
+	return dropped
+}
+
+// FanInBuffered is like FanIn, but puts a bounded buffer between the inputs and the
+// output, so a slow consumer doesn't immediately hold up producers. When the buffer
+// fills up, config.Overflow decides whether producers wait, or values get dropped
+func FanInBuffered[T any](ctx context.Context, config BufferConfig, inputs ...<-chan T) *Buffered[T] {
+	type received struct {
+		index int
+		value T
+		ok    bool
+	}
+
+	out := make(chan T)
+	b := &Buffered[T]{output: out, dropped: make([]atomic.Int64, len(inputs))}
+	size := max(config.Size, 1)
+	sampleEvery := max(config.SampleEvery, 1)
+
+	receivedc := make(chan received)
+	for i, input := range inputs {
+		go func(i int, input <-chan T) {
+			for {
+				select {
+				case v, ok := <-input:
+					select {
+					case receivedc <- received{i, v, ok}:
+					case <-ctx.Done():
+						return
+					}
+					if !ok {
+						return
+					}
+				case <-ctx.Done():
+					return
+				}
+			}
+		}(i, input)
+	}
+
+	go func() {
+		defer close(out)
+		var pending []received // the buffer, oldest first
+		open := len(inputs)
+		overflowed := 0 // how many values have overflowed, for sampling
+
+		for {
+			if open == 0 && len(pending) == 0 {
+				return
+			}
+
+			// nil channel trick, as in rss.sub. Receiving is disabled once all inputs
+			// are closed, or if we're blocking producers until there's room
+			incoming := receivedc
+			if open == 0 || (config.Overflow == Block && len(pending) >= size) {
+				incoming = nil
+			}
+			// ... and sending is disabled when there's nothing to send
+			var updates chan T
+			var first T
+			if len(pending) > 0 {
+				first = pending[0].value
+				updates = out
+			}
+
+			select {
+			case r := <-incoming:
+				if !r.ok {
+					open--
+					break
+				}
+				if len(pending) < size {
+					pending = append(pending, r)
+					break
+				}
+				overflowed++
+				switch {
+				case config.Overflow == DropOldest,
+					config.Overflow == Sample && overflowed%sampleEvery == 0:
+					b.dropped[pending[0].index].Add(1)
+					pending = append(pending[1:], r)
+				default:
+					b.dropped[r.index].Add(1)
+				}
+
+			case updates <- first:
+				pending = pending[1:] // after sending, remove the item from pending
+
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return b
+}
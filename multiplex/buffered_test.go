@@ -0,0 +1,78 @@
+package multiplex
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestFanInBuffered(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   BufferConfig
+		expected []int
+	}{
+		{"Block", BufferConfig{Size: 3, Overflow: Block}, []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}},
+		{"DropNewest", BufferConfig{Size: 3, Overflow: DropNewest}, []int{0, 1, 2}},
+		{"DropOldest", BufferConfig{Size: 3, Overflow: DropOldest}, []int{7, 8, 9}},
+		{"Sample", BufferConfig{Size: 3, Overflow: Sample, SampleEvery: 2}, []int{4, 6, 8}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			values := []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}
+			b := FanInBuffered(context.Background(), test.config, sendAll(values...))
+
+			// Don't read anything until the producer has sent everything, so the buffer
+			// is forced to overflow
+			expectedDropped := int64(len(values) - len(test.expected))
+			if test.config.Overflow != Block {
+				deadline := time.Now().Add(1 * time.Second)
+				for b.Dropped()[0] < expectedDropped && time.Now().Before(deadline) {
+					time.Sleep(time.Millisecond)
+				}
+			}
+
+			var received []int
+			for v := range b.Output() {
+				received = append(received, v)
+			}
+			if !reflect.DeepEqual(received, test.expected) {
+				t.Errorf("Expected to receive %v, got %v", test.expected, received)
+			}
+			if dropped := b.Dropped()[0]; dropped != expectedDropped {
+				t.Errorf("Expected %d values to be dropped, was %d", expectedDropped, dropped)
+			}
+		})
+	}
+}
+
+func TestFanInBufferedDoesNotBlockProducers(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	input := make(chan int)
+	b := FanInBuffered(ctx, BufferConfig{Size: 1, Overflow: DropNewest}, input, sendAll(1, 2))
+
+	// Nobody is reading, but sends still go through
+	for i := 0; i < 10; i++ {
+		select {
+		case input <- i:
+		case <-time.After(1 * time.Second):
+			t.Fatalf("Expected producer not to be blocked, but send %d timed out", i)
+		}
+	}
+
+	// Everything except the one value in the buffer gets dropped
+	deadline := time.Now().Add(1 * time.Second)
+	for {
+		dropped := b.Dropped()
+		if dropped[0]+dropped[1] == 11 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected 11 values to be dropped, but dropped %v", dropped)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -1,5 +1,10 @@
 package multiplex
 
+import (
+	"context"
+	"sync"
+)
+
 // FanInNaive receives from 2 channels, sends to an output channel
 // This is a naive implementation, using 2 goroutines, instead of select
 func FanInNaive(input1, input2 <-chan string) <-chan string {
@@ -19,26 +24,46 @@ func FanInNaive(input1, input2 <-chan string) <-chan string {
 	return out
 }
 
-// FanIn receives from 2 channels, send to an output channel
-// This implementation uses select
+// FanIn receives from any number of channels, and sends everything it receives to
+// a single output channel. The output channel is closed once all inputs have been
+// closed, or once ctx is cancelled, whichever comes first
 //
-// The select statement provides another way to handle multiple channels.
-// It's like a switch statement, but each case is a communication:
+// Each input gets its own goroutine, which forwards values to the output. Each
+// forwarder uses a select statement, so it can give up as soon as ctx is done,
+// even if it's blocked waiting for a value, or waiting for someone to receive one.
+// The select statement is like a switch statement, but each case is a communication:
 //  * all channels are evaluated
 //  * selection blocks until one communication can proceed, which then does
 //  * if multiple can proceed, select chooses one pseudo-randomly
-//  * a default clause, if present, executes immediately if not channel is ready
-func FanIn(input1, input2 <-chan string) <-chan string {
-	out := make(chan string)
-	go func() {
-		for {
-			select {
-			case s := <-input1:
-				out <- s
-			case s := <-input2:
-				out <- s
+//  * a default clause, if present, executes immediately if no channel is ready
+func FanIn[T any](ctx context.Context, inputs ...<-chan T) <-chan T {
+	out := make(chan T)
+	var wg sync.WaitGroup
+	wg.Add(len(inputs))
+	for _, input := range inputs {
+		go func(input <-chan T) {
+			defer wg.Done()
+			for {
+				select {
+				case v, ok := <-input:
+					if !ok {
+						return // this input is exhausted
+					}
+					select {
+					case out <- v:
+					case <-ctx.Done():
+						return
+					}
+				case <-ctx.Done():
+					return
+				}
 			}
-		}
+		}(input)
+	}
+	// Once every forwarder has exited, nobody can send on out, so it's safe to close it
+	go func() {
+		wg.Wait()
+		close(out)
 	}()
 	return out
 }
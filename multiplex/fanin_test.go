@@ -1,6 +1,7 @@
 package multiplex
 
 import (
+	"context"
 	"testing"
 	"time"
 
@@ -8,25 +9,35 @@ import (
 )
 
 func TestFanIn(t *testing.T) {
+	clock := schedule.NewFakeClock(time.Now())
 	delay := 20 * time.Millisecond
 	message1 := "test1"
 	message2 := "test2"
 	n := 10
 
-	c := FanIn(schedule.Every(delay, message1), schedule.Every(delay, message2))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	c := FanIn(
+		ctx,
+		schedule.Every(ctx, clock, delay, func() string { return message1 }).Output(),
+		schedule.Every(ctx, clock, delay, func() string { return message2 }).Output(),
+	)
 	messages := []string{}
-	start := time.Now()
-
-	// receive n messages from channel
-	for i := 0; i < n; i++ {
-		actualMessage := <-c
-		if !(actualMessage == message1 || actualMessage == message2) {
-			t.Errorf(
-				"Expected value at index %d to be %q or %q, was %q",
-				i, message1, message2, actualMessage,
-			)
+
+	// receive n messages from channel. Each time the clock moves forward by delay, both
+	// inputs send a message
+	for i := 0; i < n; i += 2 {
+		clock.Advance(delay)
+		for j := 0; j < 2; j++ {
+			actualMessage := <-c
+			if !(actualMessage == message1 || actualMessage == message2) {
+				t.Errorf(
+					"Expected value at index %d to be %q or %q, was %q",
+					i+j, message1, message2, actualMessage,
+				)
+			}
+			messages = append(messages, actualMessage)
 		}
-		messages = append(messages, <-c)
 	}
 
 	// Make sure we received n messages
@@ -36,39 +47,91 @@ func TestFanIn(t *testing.T) {
 			n, len(messages),
 		)
 	}
+}
+
+func TestFanInClosesWhenInputsClose(t *testing.T) {
+	n := 5
+	inputs := []<-chan int{}
+	for i := 0; i < 3; i++ {
+		c := make(chan int)
+		go func() {
+			for j := 0; j < n; j++ {
+				c <- j
+			}
+			close(c)
+		}()
+		inputs = append(inputs, c)
+	}
+
+	counter := 0
+	sum := 0
+	for v := range FanIn(context.Background(), inputs...) {
+		counter++
+		sum += v
+	}
+
+	expectedCount := 3 * n
+	expectedSum := 3 * (n * (n - 1) / 2)
+	if counter != expectedCount {
+		t.Errorf("Expected to receive %d values, instead received %d", expectedCount, counter)
+	}
+	if sum != expectedSum {
+		t.Errorf("Expected values to sum to %d, was %d", expectedSum, sum)
+	}
+}
 
-	// Make sure the delays were respected
-	runtime := time.Since(start)
-	minRuntime := time.Duration(n) * delay / 2 // divide by 2 because we execute with concurrency of 2
-	maxRuntime := 3 * minRuntime               // in a perfect world runtime is exactly minRuntime, but give a buffer
-	if runtime < minRuntime {
-		t.Errorf("Expected runtime to be at least %v, was %v", minRuntime, runtime)
+func TestFanInNoInputs(t *testing.T) {
+	if v, ok := <-FanIn[string](context.Background()); ok {
+		t.Errorf("Expected output channel to be closed, but delivered %q", v)
 	}
-	if runtime > maxRuntime {
-		t.Errorf("Expected runtime to be at most %v, was %v", maxRuntime, runtime)
+}
+
+func TestFanInCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	input1 := make(chan string) // never sends, never closes
+	input2 := make(chan string)
+	c := FanIn(ctx, input1, input2)
+
+	cancel()
+	select {
+	case v, ok := <-c:
+		if ok {
+			t.Errorf("Expected output channel to be closed, but delivered %q", v)
+		}
+	case <-time.After(1 * time.Second):
+		t.Errorf("Expected output channel to be closed after cancelling")
 	}
 }
 
 func TestFanInNaive(t *testing.T) {
+	clock := schedule.NewFakeClock(time.Now())
 	delay := 20 * time.Millisecond
 	message1 := "test1"
 	message2 := "test2"
 	n := 10
 
-	c := FanInNaive(schedule.Every(delay, message1), schedule.Every(delay, message2))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	c := FanInNaive(
+		schedule.Every(ctx, clock, delay, func() string { return message1 }).Output(),
+		schedule.Every(ctx, clock, delay, func() string { return message2 }).Output(),
+	)
 	messages := []string{}
-	start := time.Now()
-
-	// receive n messages from channel
-	for i := 0; i < n; i++ {
-		actualMessage := <-c
-		if !(actualMessage == message1 || actualMessage == message2) {
-			t.Errorf(
-				"Expected value at index %d to be %q or %q, was %q",
-				i, message1, message2, actualMessage,
-			)
+
+	// receive n messages from channel. Each time the clock moves forward by delay, both
+	// inputs send a message
+	for i := 0; i < n; i += 2 {
+		clock.Advance(delay)
+		for j := 0; j < 2; j++ {
+			actualMessage := <-c
+			if !(actualMessage == message1 || actualMessage == message2) {
+				t.Errorf(
+					"Expected value at index %d to be %q or %q, was %q",
+					i+j, message1, message2, actualMessage,
+				)
+			}
+			messages = append(messages, actualMessage)
 		}
-		messages = append(messages, <-c)
 	}
 
 	// Make sure we received n messages
@@ -78,15 +141,4 @@ func TestFanInNaive(t *testing.T) {
 			n, len(messages),
 		)
 	}
-
-	// Make sure the delays were respected
-	runtime := time.Since(start)
-	minRuntime := time.Duration(n) * delay / 2 // divide by 2 because we execute with concurrency of 2
-	maxRuntime := 3 * minRuntime               // in a perfect world runtime is exactly minRuntime, but give a buffer
-	if runtime < minRuntime {
-		t.Errorf("Expected runtime to be at least %v, was %v", minRuntime, runtime)
-	}
-	if runtime > maxRuntime {
-		t.Errorf("Expected runtime to be at most %v, was %v", maxRuntime, runtime)
-	}
 }
@@ -0,0 +1,99 @@
+package multiplex
+
+import (
+	"context"
+	"hash/fnv"
+)
+
+// Strategy decides which of the outputs a value should be sent to. It's only ever
+// called from the single goroutine that FanOut starts, so it can safely keep state
+// (e.g. a round-robin counter) without any locking
+type Strategy[T any] func(v T, outputs []chan T) []chan T
+
+// RoundRobin sends each value to the next output in turn
+func RoundRobin[T any]() Strategy[T] {
+	next := 0
+	return func(v T, outputs []chan T) []chan T {
+		out := outputs[next]
+		next = (next + 1) % len(outputs)
+		return []chan T{out}
+	}
+}
+
+// LeastLoaded sends each value to the output with the fewest values waiting in
+// its buffer. Only makes sense if FanOut was given a buffer size greater than 0,
+// otherwise every output looks equally loaded, and the first one always wins
+func LeastLoaded[T any]() Strategy[T] {
+	return func(v T, outputs []chan T) []chan T {
+		least := outputs[0]
+		for _, out := range outputs[1:] {
+			if len(out) < len(least) {
+				least = out
+			}
+		}
+		return []chan T{least}
+	}
+}
+
+// KeyHash sends all values with the same key to the same output, which is handy
+// when a worker needs to see every value for a given key (e.g. all items for a feed)
+func KeyHash[T any](key func(T) string) Strategy[T] {
+	return func(v T, outputs []chan T) []chan T {
+		h := fnv.New32a()
+		h.Write([]byte(key(v)))
+		return []chan T{outputs[h.Sum32()%uint32(len(outputs))]}
+	}
+}
+
+// Broadcast sends every value to every output. A slow output slows down all the
+// others, as each value must be delivered everywhere before the next is read
+func Broadcast[T any]() Strategy[T] {
+	return func(v T, outputs []chan T) []chan T {
+		return outputs
+	}
+}
+
+// FanOut is the opposite of FanIn: it receives from a single input channel, and
+// distributes the values across n output channels, according to strategy. Each
+// output has a buffer of the given size. All outputs are closed once the input is
+// closed, or once ctx is cancelled, whichever comes first
+//
+// Combined with FanIn, this gives you a worker pool: fan out work to n workers,
+// then fan their results back in
+func FanOut[T any](ctx context.Context, input <-chan T, n, buffer int, strategy Strategy[T]) []<-chan T {
+	outputs := make([]chan T, n)
+	readOnly := make([]<-chan T, n)
+	for i := range outputs {
+		outputs[i] = make(chan T, buffer)
+		readOnly[i] = outputs[i]
+	}
+
+	go func() {
+		defer func() {
+			for _, out := range outputs {
+				close(out)
+			}
+		}()
+		if n == 0 {
+			return
+		}
+		for {
+			select {
+			case v, ok := <-input:
+				if !ok {
+					return
+				}
+				for _, out := range strategy(v, outputs) {
+					select {
+					case out <- v:
+					case <-ctx.Done():
+						return
+					}
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return readOnly
+}
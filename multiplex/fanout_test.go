@@ -0,0 +1,152 @@
+package multiplex
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// generate sends the values 0 to n-1 on a channel, then closes it
+func generate(n int) <-chan int {
+	c := make(chan int)
+	go func() {
+		for i := 0; i < n; i++ {
+			c <- i
+		}
+		close(c)
+	}()
+	return c
+}
+
+// collect concurrently drains every output, returning what each one received
+func collect[T any](outputs []<-chan T) [][]T {
+	received := make([][]T, len(outputs))
+	var wg sync.WaitGroup
+	wg.Add(len(outputs))
+	for i, out := range outputs {
+		go func(i int, out <-chan T) {
+			defer wg.Done()
+			for v := range out {
+				received[i] = append(received[i], v)
+			}
+		}(i, out)
+	}
+	wg.Wait()
+	return received
+}
+
+func TestFanOutRoundRobin(t *testing.T) {
+	n := 3
+	received := collect(FanOut(context.Background(), generate(9), n, 0, RoundRobin[int]()))
+
+	for i, values := range received {
+		if len(values) != 3 {
+			t.Errorf("Expected output %d to receive 3 values, received %v", i, values)
+			continue
+		}
+		for j, v := range values {
+			if expected := i + j*n; v != expected {
+				t.Errorf("Expected value %d of output %d to be %d, was %d", j, i, expected, v)
+			}
+		}
+	}
+}
+
+func TestFanOutKeyHash(t *testing.T) {
+	// Keys are the value mod 4, so every value with the same key must land on the same output
+	key := func(v int) string { return strconv.Itoa(v % 4) }
+	received := collect(FanOut(context.Background(), generate(100), 3, 0, KeyHash(key)))
+
+	outputForKey := map[string]int{}
+	total := 0
+	for i, values := range received {
+		for _, v := range values {
+			total++
+			if o, ok := outputForKey[key(v)]; ok && o != i {
+				t.Errorf("Expected key %q to always go to output %d, but %d went to output %d", key(v), o, v, i)
+			}
+			outputForKey[key(v)] = i
+		}
+	}
+	if total != 100 {
+		t.Errorf("Expected to receive 100 values, instead received %d", total)
+	}
+}
+
+func TestFanOutBroadcast(t *testing.T) {
+	n := 10
+	received := collect(FanOut(context.Background(), generate(n), 3, 0, Broadcast[int]()))
+
+	for i, values := range received {
+		if len(values) != n {
+			t.Errorf("Expected output %d to receive %d values, received %d", i, n, len(values))
+		}
+	}
+}
+
+func TestFanOutLeastLoaded(t *testing.T) {
+	input := make(chan int)
+	outputs := FanOut(context.Background(), input, 2, 5, LeastLoaded[int]())
+
+	// Nobody is reading yet, so values should be spread evenly across both buffers
+	for i := 0; i < 4; i++ {
+		input <- i
+	}
+	close(input)
+
+	// The last value may still be on its way to a buffer, so wait for it to land
+	deadline := time.Now().Add(1 * time.Second)
+	for len(outputs[0])+len(outputs[1]) < 4 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	for i, out := range outputs {
+		if len(out) != 2 {
+			t.Errorf("Expected output %d to have 2 values buffered, had %d", i, len(out))
+		}
+	}
+}
+
+func TestFanOutCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	outputs := FanOut(ctx, make(chan int), 3, 0, RoundRobin[int]())
+
+	cancel()
+	for i, out := range outputs {
+		select {
+		case v, ok := <-out:
+			if ok {
+				t.Errorf("Expected output %d to be closed, but delivered %d", i, v)
+			}
+		case <-time.After(1 * time.Second):
+			t.Errorf("Expected output %d to be closed after cancelling", i)
+		}
+	}
+}
+
+func TestFanOutFanIn(t *testing.T) {
+	ctx := context.Background()
+	n := 50
+
+	// Fan out to some workers that square values, then fan their results back in
+	var results []<-chan int
+	for _, work := range FanOut(ctx, generate(n), 4, 0, RoundRobin[int]()) {
+		result := make(chan int)
+		go func(work <-chan int) {
+			for v := range work {
+				result <- v * v
+			}
+			close(result)
+		}(work)
+		results = append(results, result)
+	}
+
+	sum := 0
+	for v := range FanIn(ctx, results...) {
+		sum += v
+	}
+	if expected := (n - 1) * n * (2*n - 1) / 6; sum != expected {
+		t.Errorf("Expected squares to sum to %d, was %d", expected, sum)
+	}
+}
@@ -0,0 +1,172 @@
+package multiplex
+
+import (
+	"errors"
+	"sync"
+)
+
+var (
+	// ErrMuxClosed is returned when using a Mux after it has been closed
+	ErrMuxClosed = errors.New("mux closed")
+	// ErrUnknownHandle is returned when removing an input that isn't part of a Mux
+	ErrUnknownHandle = errors.New("unknown handle")
+)
+
+// Handle identifies an input that has been added to a Mux, so it can be removed later
+type Handle int
+
+// Mux is a fan in whose inputs can be added and removed while it's running. Like
+// rss.Subscribe, all its state is owned by a single goroutine (loop), and the
+// methods just send requests to that goroutine over channels, so there's no need
+// for a mutex
+type Mux[T any] struct {
+	output   chan T
+	adding   chan addRequest[T]
+	removing chan removeRequest
+	closing  chan chan error
+	finished chan Handle   // forwarders report here when their input is closed
+	done     chan struct{} // closed once loop has exited, so requests don't block forever
+}
+
+type addRequest[T any] struct {
+	input <-chan T
+	reply chan Handle
+}
+
+type removeRequest struct {
+	handle Handle
+	reply  chan error
+}
+
+// forwarder is a goroutine moving values from one input to the output
+type forwarder struct {
+	stop   chan struct{} // closed to ask the forwarder to exit
+	exited chan struct{} // closed by the forwarder once it has exited
+}
+
+// NewMux creates a Mux with no inputs, and starts it running
+func NewMux[T any]() *Mux[T] {
+	m := &Mux[T]{
+		output:   make(chan T),
+		adding:   make(chan addRequest[T]),
+		removing: make(chan removeRequest),
+		closing:  make(chan chan error),
+		finished: make(chan Handle),
+		done:     make(chan struct{}),
+	}
+	go m.loop()
+	return m
+}
+
+// Output is the merged channel of all inputs. It's closed when the Mux is closed
+func (m *Mux[T]) Output() <-chan T {
+	return m.output
+}
+
+// Add starts merging an input into the output. The input is removed automatically
+// once it's closed, or it can be removed early with Remove
+func (m *Mux[T]) Add(input <-chan T) (Handle, error) {
+	reply := make(chan Handle)
+	select {
+	case m.adding <- addRequest[T]{input, reply}:
+		return <-reply, nil
+	case <-m.done:
+		return 0, ErrMuxClosed
+	}
+}
+
+// Remove stops merging an input into the output. Once Remove returns, no more values
+// from that input will be delivered
+func (m *Mux[T]) Remove(h Handle) error {
+	reply := make(chan error)
+	select {
+	case m.removing <- removeRequest{h, reply}:
+		return <-reply
+	case <-m.done:
+		return ErrMuxClosed
+	}
+}
+
+// Close removes all inputs, and closes the output
+func (m *Mux[T]) Close() error {
+	errchan := make(chan error)
+	select {
+	case m.closing <- errchan:
+		return <-errchan
+	case <-m.done:
+		return ErrMuxClosed
+	}
+}
+
+// loop owns the set of inputs, and handles requests from Add, Remove and Close,
+// until it's closed
+func (m *Mux[T]) loop() {
+	forwarders := make(map[Handle]forwarder)
+	var next Handle
+	var wg sync.WaitGroup
+
+	for {
+		select {
+		case req := <-m.adding:
+			next++
+			f := forwarder{stop: make(chan struct{}), exited: make(chan struct{})}
+			forwarders[next] = f
+			wg.Add(1)
+			go func(h Handle, input <-chan T) {
+				defer wg.Done()
+				defer close(f.exited)
+				m.forward(h, input, f.stop)
+			}(next, req.input)
+			req.reply <- next
+
+		case req := <-m.removing:
+			f, ok := forwarders[req.handle]
+			if !ok {
+				req.reply <- ErrUnknownHandle
+				break
+			}
+			delete(forwarders, req.handle)
+			close(f.stop)
+			<-f.exited // it won't take long, both cases in forward watch f.stop
+			req.reply <- nil
+
+		// An input was closed, so its forwarder has gone away on its own
+		case h := <-m.finished:
+			delete(forwarders, h)
+
+		case errchan := <-m.closing:
+			for _, f := range forwarders {
+				close(f.stop)
+			}
+			wg.Wait()
+			close(m.output) // tells receiver we're done
+			close(m.done)
+			errchan <- nil
+			return
+		}
+	}
+}
+
+// forward sends everything from input to the output, until input is closed, or
+// it's asked to stop
+func (m *Mux[T]) forward(h Handle, input <-chan T, stop chan struct{}) {
+	for {
+		select {
+		case v, ok := <-input:
+			if !ok {
+				select {
+				case m.finished <- h:
+				case <-stop:
+				}
+				return
+			}
+			select {
+			case m.output <- v:
+			case <-stop:
+				return
+			}
+		case <-stop:
+			return
+		}
+	}
+}
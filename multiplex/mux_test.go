@@ -0,0 +1,93 @@
+package multiplex
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMux(t *testing.T) {
+	m := NewMux[string]()
+	input1 := make(chan string)
+	input2 := make(chan string)
+
+	h1, err := m.Add(input1)
+	if err != nil {
+		t.Fatalf("Expected no error adding input, but got %v", err)
+	}
+	if _, err := m.Add(input2); err != nil {
+		t.Fatalf("Expected no error adding input, but got %v", err)
+	}
+
+	go func() { input1 <- "one" }()
+	if v := <-m.Output(); v != "one" {
+		t.Errorf("Expected %q, got %q", "one", v)
+	}
+	go func() { input2 <- "two" }()
+	if v := <-m.Output(); v != "two" {
+		t.Errorf("Expected %q, got %q", "two", v)
+	}
+
+	// Once removed, nothing is read from the input any more
+	if err := m.Remove(h1); err != nil {
+		t.Errorf("Expected no error removing input, but got %v", err)
+	}
+	select {
+	case input1 <- "ignored":
+		t.Errorf("Expected removed input not to be read from")
+	case <-time.After(20 * time.Millisecond):
+	}
+	if err := m.Remove(h1); err != ErrUnknownHandle {
+		t.Errorf("Expected %v removing input twice, but got %v", ErrUnknownHandle, err)
+	}
+
+	// Closing closes the output, and the mux can't be used after that
+	if err := m.Close(); err != nil {
+		t.Errorf("Expected no error closing, but got %v", err)
+	}
+	if v, ok := <-m.Output(); ok {
+		t.Errorf("Expected output channel to be closed, but delivered %q", v)
+	}
+	if _, err := m.Add(input1); err != ErrMuxClosed {
+		t.Errorf("Expected %v adding after close, but got %v", ErrMuxClosed, err)
+	}
+	if err := m.Close(); err != ErrMuxClosed {
+		t.Errorf("Expected %v closing twice, but got %v", ErrMuxClosed, err)
+	}
+}
+
+func TestMuxInputClosed(t *testing.T) {
+	m := NewMux[int]()
+	defer m.Close()
+	m.Add(sendAll(1, 2, 3))
+	m.Add(sendAll(4, 5))
+
+	sum := 0
+	for i := 0; i < 5; i++ {
+		sum += <-m.Output()
+	}
+	if sum != 15 {
+		t.Errorf("Expected values to sum to 15, was %d", sum)
+	}
+
+	// Closed inputs are dropped, but the output stays open for new inputs
+	m.Add(sendAll(6))
+	if v := <-m.Output(); v != 6 {
+		t.Errorf("Expected 6, got %d", v)
+	}
+}
+
+func TestMuxCloseWithBlockedInputs(t *testing.T) {
+	m := NewMux[int]()
+	m.Add(sendAll(1, 2, 3)) // nobody ever reads these
+
+	closed := make(chan error)
+	go func() { closed <- m.Close() }()
+	select {
+	case err := <-closed:
+		if err != nil {
+			t.Errorf("Expected no error closing, but got %v", err)
+		}
+	case <-time.After(1 * time.Second):
+		t.Errorf("Expected Close not to block on inputs nobody is reading")
+	}
+}
@@ -0,0 +1,145 @@
+package multiplex
+
+import (
+	"context"
+	"time"
+)
+
+// FanInOrdered merges several channels that are each already sorted (according to
+// less) into a single, globally sorted output channel. It's a k-way merge: it
+// holds the next value from every input, and always emits the smallest one
+//
+// To know which value is smallest, it must wait until every open input has either
+// sent a value or been closed, so a single slow producer holds everything up. If
+// lateness is greater than 0, an input that has kept everyone waiting for longer
+// than lateness is skipped, until it sends something. Values that show up late
+// like this are still merged, but may come out of order relative to values that
+// have already been emitted. A lateness of 0 waits forever
+//
+// The output channel is closed once all inputs are closed and drained, or once
+// ctx is cancelled, whichever comes first
+func FanInOrdered[T any](ctx context.Context, less func(a, b T) bool, lateness time.Duration, inputs ...<-chan T) <-chan T {
+	type head struct {
+		index int
+		value T
+		ok    bool
+	}
+
+	out := make(chan T)
+	heads := make(chan head)                   // forwarders report the next value from their input here
+	more := make([]chan struct{}, len(inputs)) // tells forwarder i to fetch another value
+
+	for i, input := range inputs {
+		more[i] = make(chan struct{}, 1)
+		go func(i int, input <-chan T) {
+			for {
+				select {
+				case <-more[i]:
+				case <-ctx.Done():
+					return
+				}
+				select {
+				case v, ok := <-input:
+					select {
+					case heads <- head{i, v, ok}:
+					case <-ctx.Done():
+						return
+					}
+					if !ok {
+						return
+					}
+				case <-ctx.Done():
+					return
+				}
+			}
+		}(i, input)
+	}
+
+	go func() {
+		defer close(out)
+
+		values := make([]T, len(inputs))
+		hasValue := make([]bool, len(inputs))
+		closed := make([]bool, len(inputs))
+		waitingSince := make([]time.Time, len(inputs)) // zero if we're not waiting on this input
+
+		// Ask every input for its first value
+		for i := range inputs {
+			more[i] <- struct{}{}
+			waitingSince[i] = time.Now()
+		}
+
+		for {
+			// Find the smallest value we're holding, and the earliest time one of the
+			// inputs we're still waiting on becomes late
+			smallest := -1
+			waiting := 0
+			var deadline time.Time
+			now := time.Now()
+			for i := range inputs {
+				if hasValue[i] && (smallest == -1 || less(values[i], values[smallest])) {
+					smallest = i
+				}
+				if closed[i] || hasValue[i] {
+					continue
+				}
+				if lateness > 0 && now.Sub(waitingSince[i]) >= lateness {
+					continue // it's late, don't wait on it any more
+				}
+				waiting++
+				if d := waitingSince[i].Add(lateness); deadline.IsZero() || d.Before(deadline) {
+					deadline = d
+				}
+			}
+
+			// Everyone we care about has reported in, so the smallest value is safe to emit
+			if waiting == 0 {
+				if smallest == -1 {
+					allClosed := true
+					for i := range inputs {
+						allClosed = allClosed && closed[i]
+					}
+					if allClosed {
+						return
+					}
+				} else {
+					select {
+					case out <- values[smallest]:
+					case <-ctx.Done():
+						return
+					}
+					var zero T
+					values[smallest], hasValue[smallest] = zero, false
+					more[smallest] <- struct{}{}
+					waitingSince[smallest] = time.Now()
+					continue
+				}
+			}
+
+			// Wait for more values. We only need a timer if we're holding a value that
+			// could be emitted once the stragglers become late
+			var timeout <-chan time.Time
+			var timer *time.Timer
+			if lateness > 0 && smallest != -1 && waiting > 0 {
+				timer = time.NewTimer(time.Until(deadline))
+				timeout = timer.C
+			}
+			select {
+			case h := <-heads:
+				waitingSince[h.index] = time.Time{}
+				if h.ok {
+					values[h.index], hasValue[h.index] = h.value, true
+				} else {
+					closed[h.index] = true
+				}
+			case <-timeout:
+			case <-ctx.Done():
+				return
+			}
+			if timer != nil {
+				timer.Stop()
+			}
+		}
+	}()
+	return out
+}
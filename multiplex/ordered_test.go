@@ -0,0 +1,112 @@
+package multiplex
+
+import (
+	"context"
+	"sort"
+	"testing"
+	"time"
+)
+
+// sendAll sends values on a channel, then closes it
+func sendAll(values ...int) <-chan int {
+	c := make(chan int)
+	go func() {
+		for _, v := range values {
+			c <- v
+		}
+		close(c)
+	}()
+	return c
+}
+
+func lessInt(a, b int) bool { return a < b }
+
+func TestFanInOrdered(t *testing.T) {
+	c := FanInOrdered(
+		context.Background(),
+		lessInt,
+		0,
+		sendAll(1, 4, 7, 10),
+		sendAll(2, 5, 8),
+		sendAll(0, 3, 6, 9, 11, 12),
+		sendAll(),
+	)
+
+	var received []int
+	for v := range c {
+		received = append(received, v)
+	}
+
+	if len(received) != 13 {
+		t.Errorf("Expected to receive 13 values, instead received %v", received)
+	}
+	if !sort.IntsAreSorted(received) {
+		t.Errorf("Expected values to be sorted, were %v", received)
+	}
+}
+
+func TestFanInOrderedWaitsForSlowInputs(t *testing.T) {
+	slow := make(chan int)
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		slow <- 1
+		close(slow)
+	}()
+
+	// With no lateness, we must wait for the slow input, so 1 still comes out first
+	var received []int
+	for v := range FanInOrdered(context.Background(), lessInt, 0, sendAll(2, 3), slow) {
+		received = append(received, v)
+	}
+	if len(received) != 3 || !sort.IntsAreSorted(received) {
+		t.Errorf("Expected [1 2 3], got %v", received)
+	}
+}
+
+func TestFanInOrderedLateness(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stalled := make(chan int) // never sends until we say so
+	c := FanInOrdered(ctx, lessInt, 20*time.Millisecond, sendAll(2, 3, 4), stalled)
+
+	// The stalled input becomes late, so we still get everything from the other input
+	start := time.Now()
+	for _, expected := range []int{2, 3, 4} {
+		select {
+		case v := <-c:
+			if v != expected {
+				t.Errorf("Expected %d, got %d", expected, v)
+			}
+		case <-time.After(1 * time.Second):
+			t.Fatalf("Expected %d, but timed out waiting", expected)
+		}
+	}
+	if runtime := time.Since(start); runtime > 500*time.Millisecond {
+		t.Errorf("Expected stalled input to only hold things up once, but took %v", runtime)
+	}
+
+	// Late values are still delivered, and the output closes once everything is closed
+	stalled <- 1
+	close(stalled)
+	if v := <-c; v != 1 {
+		t.Errorf("Expected late value 1, got %d", v)
+	}
+	if v, ok := <-c; ok {
+		t.Errorf("Expected output channel to be closed, but delivered %d", v)
+	}
+}
+
+func TestFanInOrderedCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	c := FanInOrdered(ctx, lessInt, 0, make(chan int), sendAll(1, 2, 3))
+
+	cancel()
+	select {
+	case v, ok := <-c:
+		if ok {
+			t.Errorf("Expected output channel to be closed after cancelling, but delivered %d", v)
+		}
+	case <-time.After(1 * time.Second):
+		t.Errorf("Expected output channel to be closed after cancelling")
+	}
+}
@@ -0,0 +1,156 @@
+package multiplex
+
+import (
+	"context"
+	"reflect"
+	"sync/atomic"
+)
+
+// Weighted is an input to FanInPriority
+type Weighted[T any] struct {
+	C        <-chan T
+	Priority int // inputs with a higher priority are always drained first
+	Weight   int // share of the output relative to other inputs with the same priority, defaults to 1
+}
+
+// Prioritized is the result of FanInPriority
+type Prioritized[T any] struct {
+	output <-chan T
+	counts []atomic.Int64
+}
+
+// Output is the merged channel, closed once all inputs are closed, or the context
+// passed to FanInPriority is cancelled
+func (p *Prioritized[T]) Output() <-chan T {
+	return p.output
+}
+
+// Counts returns how many values each input has contributed to the output so far,
+// in the same order the inputs were passed to FanInPriority
+func (p *Prioritized[T]) Counts() []int64 {
+	counts := make([]int64, len(p.counts))
+	for i := range p.counts {
+		counts[i] = p.counts[i].Load()
+	}
+	return counts
+}
+
+// FanInPriority is like FanIn, but rather than letting select pick pseudo-randomly
+// between inputs, it picks based on priority and weight:
+//  * if inputs with different priorities have values ready, the highest priority
+//    one always wins, so e.g. control messages can jump ahead of bulk data
+//  * between inputs with the same priority, values are picked in proportion to
+//    their weights (using smooth weighted round-robin), so a heavy input gets a
+//    bigger share without starving lighter ones
+//
+// It only picks between values that are ready, so an input that can't keep up gets
+// a smaller share than its weight, rather than holding everyone else up
+func FanInPriority[T any](ctx context.Context, inputs ...Weighted[T]) *Prioritized[T] {
+	out := make(chan T)
+	p := &Prioritized[T]{output: out, counts: make([]atomic.Int64, len(inputs))}
+
+	go func() {
+		defer close(out)
+		held := make([]T, len(inputs)) // the next value from each input, if we have one
+		hasValue := make([]bool, len(inputs))
+		closed := make([]bool, len(inputs))
+		current := make([]int, len(inputs)) // smooth weighted round-robin state
+		hold := func(i int, v T, ok bool) {
+			if ok {
+				held[i], hasValue[i] = v, true
+			} else {
+				closed[i] = true
+			}
+		}
+
+		for {
+			// Top up our held values from any inputs that are ready, without blocking,
+			// so we see every value that's already waiting before we pick
+			allClosed := true
+			for i, input := range inputs {
+				if !hasValue[i] && !closed[i] {
+					select {
+					case v, ok := <-input.C:
+						hold(i, v, ok)
+					default:
+					}
+				}
+				allClosed = allClosed && closed[i] && !hasValue[i]
+			}
+			if allClosed {
+				return
+			}
+
+			pick := pickWeighted(inputs, hasValue, current)
+			if pick == -1 {
+				// Nothing's ready, so wait for any open input. A select statement needs its
+				// cases fixed at compile time, reflect.Select lets us build them at runtime
+				cases := []reflect.SelectCase{{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(ctx.Done())}}
+				var open []int
+				for i, input := range inputs {
+					if !closed[i] {
+						cases = append(cases, reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(input.C)})
+						open = append(open, i)
+					}
+				}
+				chosen, v, ok := reflect.Select(cases)
+				if chosen == 0 {
+					return
+				}
+				var value T
+				if ok {
+					value = v.Interface().(T)
+				}
+				hold(open[chosen-1], value, ok)
+				continue
+			}
+
+			select {
+			case out <- held[pick]:
+				var zero T
+				held[pick], hasValue[pick] = zero, false
+				p.counts[pick].Add(1)
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return p
+}
+
+// pickWeighted returns the index of the input that should be sent next, among those
+// that have a value, or -1 if none do. It considers only the highest priority with
+// a value ready, then uses smooth weighted round-robin (as in nginx) between those:
+// every candidate earns its weight, the richest wins and pays back the total
+func pickWeighted[T any](inputs []Weighted[T], hasValue []bool, current []int) int {
+	top := 0
+	found := false
+	for i, input := range inputs {
+		if hasValue[i] && (!found || input.Priority > top) {
+			top = input.Priority
+			found = true
+		}
+	}
+	if !found {
+		return -1
+	}
+
+	pick := -1
+	total := 0
+	for i, input := range inputs {
+		if !hasValue[i] || input.Priority != top {
+			continue
+		}
+		weight := input.Weight
+		if weight <= 0 {
+			weight = 1
+		}
+		current[i] += weight
+		total += weight
+		if pick == -1 || current[i] > current[pick] {
+			pick = i
+		}
+	}
+	current[pick] -= total
+	return pick
+}
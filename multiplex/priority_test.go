@@ -0,0 +1,90 @@
+package multiplex
+
+import (
+	"context"
+	"testing"
+)
+
+// filled returns a closed channel, with n copies of v waiting in its buffer
+func filled(v string, n int) <-chan string {
+	c := make(chan string, n)
+	for i := 0; i < n; i++ {
+		c <- v
+	}
+	close(c)
+	return c
+}
+
+func TestFanInPriorityStrict(t *testing.T) {
+	n := 20
+	p := FanInPriority(
+		context.Background(),
+		Weighted[string]{C: filled("low", n), Priority: 0},
+		Weighted[string]{C: filled("high", n), Priority: 1},
+	)
+
+	var received []string
+	for v := range p.Output() {
+		received = append(received, v)
+	}
+	if len(received) != 2*n {
+		t.Fatalf("Expected to receive %d values, instead received %d", 2*n, len(received))
+	}
+
+	// Every value is ready from the start, so every high priority value should drain
+	// before the low priority ones
+	for i, v := range received {
+		if (i < n) != (v == "high") {
+			t.Errorf("Expected all high priority values before low ones, got %q at index %d", v, i)
+			break
+		}
+	}
+
+	counts := p.Counts()
+	if counts[0] != int64(n) || counts[1] != int64(n) {
+		t.Errorf("Expected counts to be [%d %d], were %v", n, n, counts)
+	}
+}
+
+func TestFanInPriorityWeighted(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	p := FanInPriority(
+		ctx,
+		Weighted[string]{C: filled("heavy", 400), Weight: 3},
+		Weighted[string]{C: filled("light", 400), Weight: 1},
+	)
+
+	// Both inputs always have a value ready, so the weights apply exactly
+	n := 400
+	received := map[string]int{}
+	for i := 0; i < n; i++ {
+		received[<-p.Output()]++
+	}
+	if received["heavy"] != 300 || received["light"] != 100 {
+		t.Errorf("Expected 300 heavy and 100 light values, got %v", received)
+	}
+}
+
+func TestFanInPriorityWaitsForInputs(t *testing.T) {
+	a := make(chan string)
+	b := make(chan string)
+	p := FanInPriority(context.Background(), Weighted[string]{C: a}, Weighted[string]{C: b})
+
+	// Nothing's ready at first, so it has to wait for a value to arrive, from whichever
+	// input it comes on
+	go func() { b <- "b" }()
+	if v := <-p.Output(); v != "b" {
+		t.Errorf("Expected b, got %q", v)
+	}
+	go func() { a <- "a" }()
+	if v := <-p.Output(); v != "a" {
+		t.Errorf("Expected a, got %q", v)
+	}
+
+	close(a)
+	close(b)
+	if v, ok := <-p.Output(); ok {
+		t.Errorf("Expected output channel to be closed, but delivered %q", v)
+	}
+}
@@ -0,0 +1,49 @@
+package multiplex
+
+import (
+	"context"
+	"time"
+)
+
+// Named is an input to FanInTagged, a channel along with a name to identify it by
+type Named[T any] struct {
+	Name string
+	C    <-chan T
+}
+
+// Tagged is a value received by FanInTagged, along with where and when it came from
+type Tagged[T any] struct {
+	Source     string    // name of the input the value came from
+	Index      int       // position of the input in the list passed to FanInTagged
+	Value      T         // the value itself
+	ReceivedAt time.Time // when the value was received from the input
+}
+
+// FanInTagged is like FanIn, but wraps every value in a Tagged envelope, so whoever's
+// consuming the merged stream can tell which input each value came from
+func FanInTagged[T any](ctx context.Context, inputs ...Named[T]) <-chan Tagged[T] {
+	tagged := make([]<-chan Tagged[T], len(inputs))
+	for i, input := range inputs {
+		c := make(chan Tagged[T])
+		go func(i int, input Named[T]) {
+			defer close(c)
+			for {
+				select {
+				case v, ok := <-input.C:
+					if !ok {
+						return
+					}
+					select {
+					case c <- Tagged[T]{Source: input.Name, Index: i, Value: v, ReceivedAt: time.Now()}:
+					case <-ctx.Done():
+						return
+					}
+				case <-ctx.Done():
+					return
+				}
+			}
+		}(i, input)
+		tagged[i] = c
+	}
+	return FanIn(ctx, tagged...)
+}
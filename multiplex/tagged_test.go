@@ -0,0 +1,34 @@
+package multiplex
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestFanInTagged(t *testing.T) {
+	start := time.Now()
+	c := FanInTagged(
+		context.Background(),
+		Named[int]{Name: "odd", C: sendAll(1, 3, 5)},
+		Named[int]{Name: "even", C: sendAll(2, 4)},
+	)
+
+	counts := map[string]int{}
+	for tagged := range c {
+		counts[tagged.Source]++
+		if expected := map[int]string{1: "odd", 0: "even"}[tagged.Value%2]; tagged.Source != expected {
+			t.Errorf("Expected %d to come from %q, came from %q", tagged.Value, expected, tagged.Source)
+		}
+		if expected := map[string]int{"odd": 0, "even": 1}[tagged.Source]; tagged.Index != expected {
+			t.Errorf("Expected %q to have index %d, was %d", tagged.Source, expected, tagged.Index)
+		}
+		if tagged.ReceivedAt.Before(start) || tagged.ReceivedAt.After(time.Now()) {
+			t.Errorf("Expected %d to be received during the test, was received at %v", tagged.Value, tagged.ReceivedAt)
+		}
+	}
+
+	if counts["odd"] != 3 || counts["even"] != 2 {
+		t.Errorf("Expected 3 odd values and 2 even values, got %v", counts)
+	}
+}
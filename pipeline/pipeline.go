@@ -0,0 +1,107 @@
+package pipeline
+
+import (
+	"context"
+)
+
+// Stage is one step in a pipeline. It reads from an input channel, and returns an
+// output channel, which it closes once the input is closed, or ctx is done. Stages
+// should always select on ctx.Done() when receiving and sending, so a cancelled
+// pipeline can shut down, even if its source has gone quiet, or nobody is reading
+// from the end of it
+type Stage[A, B any] func(ctx context.Context, in <-chan A) <-chan B
+
+// Then chains two stages together, so the output of first is the input of second
+func Then[A, B, C any](first Stage[A, B], second Stage[B, C]) Stage[A, C] {
+	return func(ctx context.Context, in <-chan A) <-chan C {
+		return second(ctx, first(ctx, in))
+	}
+}
+
+// failKey is the context key Run stores its cancel func under, see Fail
+type failKey struct{}
+
+// Fail stops the pipeline ctx belongs to, with err as the reason. Only the first
+// failure counts, it's what Pipeline.Err returns. Stages call this when they hit an
+// error, rather than trying to send errors down the pipeline. It does nothing if
+// ctx doesn't come from Run
+func Fail(ctx context.Context, err error) {
+	if cancel, ok := ctx.Value(failKey{}).(context.CancelCauseFunc); ok {
+		cancel(err)
+	}
+}
+
+// Pipeline is a running pipeline, see Run
+type Pipeline[T any] struct {
+	output <-chan T
+	done   chan struct{}
+	err    error
+}
+
+// Run starts a pipeline, feeding the channel source returns into stage. If any stage
+// fails, or ctx is cancelled, every stage is cancelled, and the output is closed early
+//
+// source is passed the pipeline's ctx, and should stop sending when it's done, so it
+// isn't left blocked when a stage fails. If you stop reading the output before it's
+// closed, cancel ctx, so the stages aren't left waiting to send forever
+func Run[A, B any](ctx context.Context, source func(ctx context.Context) <-chan A, stage Stage[A, B]) *Pipeline[B] {
+	ctx, cancel := context.WithCancelCause(ctx)
+	ctx = context.WithValue(ctx, failKey{}, cancel)
+	out := make(chan B)
+	p := &Pipeline[B]{output: out, done: make(chan struct{})}
+
+	in := stage(ctx, source(ctx))
+	go func() {
+		defer close(p.done)
+		defer cancel(nil)
+		defer close(out)
+		for v := range in {
+			if !send(ctx, out, v) {
+				// Keep going until the stages have shut down, so we know the cause is final
+				for range in {
+				}
+				break
+			}
+		}
+		if ctx.Err() != nil {
+			p.err = context.Cause(ctx)
+		}
+	}()
+	return p
+}
+
+// Output is the channel the last stage's values are sent to. It's closed once the
+// pipeline is done
+func (p *Pipeline[T]) Output() <-chan T {
+	return p.output
+}
+
+// Err blocks until the pipeline is done, then returns why it stopped early: the first
+// error passed to Fail, or the cause of ctx being cancelled. It's nil if the pipeline
+// ran to completion
+func (p *Pipeline[T]) Err() error {
+	<-p.done
+	return p.err
+}
+
+// receive receives a value from in, returning false if in was closed, or ctx was
+// cancelled first
+func receive[T any](ctx context.Context, in <-chan T) (T, bool) {
+	select {
+	case v, ok := <-in:
+		return v, ok
+	case <-ctx.Done():
+		var zero T
+		return zero, false
+	}
+}
+
+// send sends v on out, returning false if ctx was cancelled first
+func send[T any](ctx context.Context, out chan<- T, v T) bool {
+	select {
+	case out <- v:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
@@ -0,0 +1,134 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// source sends values to a channel, stopping early if the pipeline's ctx is done
+func source[T any](values ...T) func(ctx context.Context) <-chan T {
+	return func(ctx context.Context) <-chan T {
+		c := make(chan T)
+		go func() {
+			defer close(c)
+			for _, v := range values {
+				if !send(ctx, c, v) {
+					return
+				}
+			}
+		}()
+		return c
+	}
+}
+
+func collect[T any](c <-chan T) []T {
+	var received []T
+	for v := range c {
+		received = append(received, v)
+	}
+	return received
+}
+
+func TestRun(t *testing.T) {
+	ctx := context.Background()
+	double := Map(func(_ context.Context, v int) (int, error) { return v * 2, nil })
+	format := Map(func(_ context.Context, v int) (string, error) { return strconv.Itoa(v), nil })
+	p := Run(ctx, source(1, 2, 3), Then(double, format))
+
+	expected := []string{"2", "4", "6"}
+	if received := collect(p.Output()); !reflect.DeepEqual(received, expected) {
+		t.Errorf("Expected %v, got %v", expected, received)
+	}
+	if err := p.Err(); err != nil {
+		t.Errorf("Expected no error, but got %v", err)
+	}
+}
+
+func TestRunFails(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	failure := errors.New("failure")
+	failOnThree := Map(func(_ context.Context, v int) (int, error) {
+		if v == 3 {
+			return 0, failure
+		}
+		return v, nil
+	})
+	identity := Map(func(_ context.Context, v int) (int, error) { return v, nil })
+	// An endless source, so the pipeline only ends because of the failure
+	endless := func(ctx context.Context) <-chan int {
+		c := make(chan int)
+		go func() {
+			for i := 1; send(ctx, c, i); i++ {
+			}
+		}()
+		return c
+	}
+	p := Run(ctx, endless, Then(failOnThree, identity))
+
+	// Values already on their way through may or may not make it out, but nothing after
+	// the failure does
+	received := collect(p.Output())
+	if expected := []int{1, 2}; !slices.Equal(received, expected[:len(received)]) {
+		t.Errorf("Expected some of %v, got %v", expected, received)
+	}
+	if err := p.Err(); !errors.Is(err, failure) {
+		t.Errorf("Expected %v, but got %v", failure, err)
+	}
+}
+
+func TestRunFailsWithQuietSource(t *testing.T) {
+	failure := errors.New("failure")
+	fail := ParallelMap(ParallelOptions{Workers: 2}, func(_ context.Context, v int) (int, error) {
+		return 0, failure
+	})
+
+	// A source that sends one value, then goes quiet without closing, until the
+	// pipeline's ctx is done
+	exited := make(chan struct{})
+	quiet := func(ctx context.Context) <-chan int {
+		c := make(chan int)
+		go func() {
+			defer close(exited)
+			if send(ctx, c, 1) {
+				<-ctx.Done()
+			}
+		}()
+		return c
+	}
+	// One worker fails, the other is left waiting on the source
+	p := Run(context.Background(), quiet, fail)
+
+	errs := make(chan error)
+	go func() { errs <- p.Err() }()
+	select {
+	case err := <-errs:
+		if !errors.Is(err, failure) {
+			t.Errorf("Expected %v, but got %v", failure, err)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Expected Err to return once a stage failed, but it's still waiting on the source")
+	}
+	select {
+	case <-exited:
+	case <-time.After(time.Second):
+		t.Errorf("Expected the source to stop once the pipeline failed")
+	}
+}
+
+func TestRunCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	identity := Map(func(_ context.Context, v int) (int, error) { return v, nil })
+	p := Run(ctx, source(1, 2, 3), identity)
+
+	<-p.Output()
+	cancel() // we've stopped reading
+	if err := p.Err(); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected %v, but got %v", context.Canceled, err)
+	}
+}
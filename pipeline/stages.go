@@ -0,0 +1,213 @@
+package pipeline
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/yashap/concurrency/schedule"
+)
+
+// Map applies f to every value. If f returns an error, the pipeline fails
+func Map[A, B any](f func(ctx context.Context, v A) (B, error)) Stage[A, B] {
+	return func(ctx context.Context, in <-chan A) <-chan B {
+		out := make(chan B)
+		go func() {
+			defer close(out)
+			for {
+				v, ok := receive(ctx, in)
+				if !ok {
+					return
+				}
+				b, err := f(ctx, v)
+				if err != nil {
+					Fail(ctx, err)
+					return
+				}
+				if !send(ctx, out, b) {
+					return
+				}
+			}
+		}()
+		return out
+	}
+}
+
+// Filter only passes on values that keep returns true for
+func Filter[T any](keep func(v T) bool) Stage[T, T] {
+	return func(ctx context.Context, in <-chan T) <-chan T {
+		out := make(chan T)
+		go func() {
+			defer close(out)
+			for {
+				v, ok := receive(ctx, in)
+				if !ok {
+					return
+				}
+				if keep(v) && !send(ctx, out, v) {
+					return
+				}
+			}
+		}()
+		return out
+	}
+}
+
+// FlatMap applies f to every value, and passes on each of the values it returns, in
+// order. If f returns an error, the pipeline fails
+func FlatMap[A, B any](f func(ctx context.Context, v A) ([]B, error)) Stage[A, B] {
+	return func(ctx context.Context, in <-chan A) <-chan B {
+		out := make(chan B)
+		go func() {
+			defer close(out)
+			for {
+				v, ok := receive(ctx, in)
+				if !ok {
+					return
+				}
+				bs, err := f(ctx, v)
+				if err != nil {
+					Fail(ctx, err)
+					return
+				}
+				for _, b := range bs {
+					if !send(ctx, out, b) {
+						return
+					}
+				}
+			}
+		}()
+		return out
+	}
+}
+
+// ParallelOptions configures ParallelMap
+type ParallelOptions struct {
+	Workers int  // how many values f can work on at once, at least 1
+	Ordered bool // send results in the order values arrived, rather than as they finish
+}
+
+// ParallelMap is like Map, but runs f on several values at once. If f returns an
+// error, the pipeline fails
+//
+// Without the Ordered option, it's a fixed pool of workers, fanning back in as results
+// come. With it, a slow value holds up the results behind it, but never more than
+// Workers of them, so memory stays bounded
+func ParallelMap[A, B any](options ParallelOptions, f func(ctx context.Context, v A) (B, error)) Stage[A, B] {
+	workers := max(options.Workers, 1)
+	if options.Ordered {
+		return orderedMap(workers, f)
+	}
+	return func(ctx context.Context, in <-chan A) <-chan B {
+		out := make(chan B)
+		var wg sync.WaitGroup
+		wg.Add(workers)
+		for i := 0; i < workers; i++ {
+			go func() {
+				defer wg.Done()
+				for {
+					v, ok := receive(ctx, in)
+					if !ok {
+						return
+					}
+					b, err := f(ctx, v)
+					if err != nil {
+						Fail(ctx, err)
+						return
+					}
+					if !send(ctx, out, b) {
+						return
+					}
+				}
+			}()
+		}
+		go func() {
+			wg.Wait()
+			close(out)
+		}()
+		return out
+	}
+}
+
+type result[T any] struct {
+	value T
+	err   error
+}
+
+// orderedMap starts a goroutine per value, up to workers at a time. Each one gets a
+// channel to put its result in, and those channels are queued up in the order values
+// arrived, so results can be read back in that order, whenever they finish
+func orderedMap[A, B any](workers int, f func(ctx context.Context, v A) (B, error)) Stage[A, B] {
+	return func(ctx context.Context, in <-chan A) <-chan B {
+		out := make(chan B)
+		pending := make(chan chan result[B], workers)
+		running := make(chan struct{}, workers) // a semaphore
+
+		go func() {
+			defer close(pending)
+			for {
+				v, ok := receive(ctx, in)
+				if !ok {
+					return
+				}
+				select {
+				case running <- struct{}{}:
+				case <-ctx.Done():
+					return
+				}
+				r := make(chan result[B], 1) // buffered, so the goroutine can always finish
+				go func() {
+					defer func() { <-running }()
+					b, err := f(ctx, v)
+					r <- result[B]{b, err}
+				}()
+				select {
+				case pending <- r:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+
+		go func() {
+			defer close(out)
+			for r := range pending {
+				res := <-r
+				if res.err != nil {
+					Fail(ctx, res.err)
+					return
+				}
+				if !send(ctx, out, res.value) {
+					return
+				}
+			}
+		}()
+		return out
+	}
+}
+
+// Timeout wraps f, so each call fails with schedule.ErrTimeout if it takes longer than
+// timeout, which fails the pipeline. Time is measured with clock. The ctx passed to f
+// is cancelled on timeout, f should return promptly once it is
+func Timeout[A, B any](clock schedule.Clock, timeout time.Duration, f func(ctx context.Context, v A) (B, error)) func(ctx context.Context, v A) (B, error) {
+	return func(ctx context.Context, v A) (B, error) {
+		ctx, cancel := context.WithCancelCause(ctx)
+		defer cancel(nil)
+		timer := clock.NewTimer(timeout)
+		defer timer.Stop()
+
+		r := make(chan result[B], 1) // buffered, so f's goroutine can finish after a timeout
+		go func() {
+			b, err := f(ctx, v)
+			r <- result[B]{b, err}
+		}()
+		select {
+		case res := <-r:
+			return res.value, res.err
+		case <-timer.C():
+			cancel(schedule.ErrTimeout)
+			var zero B
+			return zero, schedule.ErrTimeout
+		}
+	}
+}
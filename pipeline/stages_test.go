@@ -0,0 +1,114 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yashap/concurrency/schedule"
+)
+
+func TestFilterAndFlatMap(t *testing.T) {
+	ctx := context.Background()
+	words := FlatMap(func(_ context.Context, line string) ([]string, error) {
+		return strings.Fields(line), nil
+	})
+	long := Filter(func(word string) bool { return len(word) > 3 })
+	p := Run(ctx, source("do not communicate", "by sharing memory"), Then(words, long))
+
+	expected := []string{"communicate", "sharing", "memory"}
+	if received := collect(p.Output()); !reflect.DeepEqual(received, expected) {
+		t.Errorf("Expected %v, got %v", expected, received)
+	}
+}
+
+func TestParallelMap(t *testing.T) {
+	ctx := context.Background()
+	square := ParallelMap(ParallelOptions{Workers: 3}, func(_ context.Context, v int) (int, error) {
+		return v * v, nil
+	})
+	p := Run(ctx, source(1, 2, 3, 4, 5), square)
+
+	// Results arrive as they finish, so in any order
+	received := collect(p.Output())
+	slices.Sort(received)
+	expected := []int{1, 4, 9, 16, 25}
+	if !reflect.DeepEqual(received, expected) {
+		t.Errorf("Expected %v, got %v", expected, received)
+	}
+}
+
+func TestParallelMapOrdered(t *testing.T) {
+	ctx := context.Background()
+	n := 4
+	started := make(chan int, n)
+	finish := make([]chan struct{}, n)
+	for i := range finish {
+		finish[i] = make(chan struct{})
+	}
+	slow := ParallelMap(ParallelOptions{Workers: n, Ordered: true}, func(_ context.Context, v int) (int, error) {
+		started <- v
+		<-finish[v]
+		return v, nil
+	})
+	p := Run(ctx, source(0, 1, 2, 3), slow)
+
+	// Once all are running at once, let them finish in reverse order
+	for i := 0; i < n; i++ {
+		<-started
+	}
+	for i := n - 1; i >= 0; i-- {
+		close(finish[i])
+	}
+
+	expected := []int{0, 1, 2, 3}
+	if received := collect(p.Output()); !reflect.DeepEqual(received, expected) {
+		t.Errorf("Expected %v, got %v", expected, received)
+	}
+}
+
+func TestParallelMapFails(t *testing.T) {
+	ctx := context.Background()
+	failure := errors.New("failure")
+	for _, ordered := range []bool{false, true} {
+		fail := ParallelMap(ParallelOptions{Workers: 2, Ordered: ordered}, func(_ context.Context, v int) (int, error) {
+			return 0, failure
+		})
+		p := Run(ctx, source(1, 2, 3), fail)
+		if received := collect(p.Output()); len(received) != 0 {
+			t.Errorf("Expected no values, got %v", received)
+		}
+		if err := p.Err(); !errors.Is(err, failure) {
+			t.Errorf("Expected %v, but got %v", failure, err)
+		}
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	ctx := context.Background()
+	clock := schedule.NewFakeClock(time.Now())
+	hang := Map(Timeout(clock, time.Second, func(ctx context.Context, v int) (int, error) {
+		if v == 1 {
+			return v, nil
+		}
+		<-ctx.Done()
+		return 0, ctx.Err()
+	}))
+	p := Run(ctx, source(1, 2), hang)
+
+	if v := <-p.Output(); v != 1 {
+		t.Errorf("Expected 1, got %d", v)
+	}
+	clock.BlockUntil(1)
+	clock.Advance(time.Second)
+	if v, ok := <-p.Output(); ok {
+		t.Errorf("Expected output channel to be closed, but delivered %d", v)
+	}
+	if err := p.Err(); !errors.Is(err, schedule.ErrTimeout) {
+		t.Errorf("Expected %v, but got %v", schedule.ErrTimeout, err)
+	}
+}
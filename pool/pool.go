@@ -0,0 +1,305 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/yashap/concurrency/future"
+	"github.com/yashap/concurrency/schedule"
+)
+
+// ErrPoolClosed is returned when submitting to a pool that's shutting down, and by
+// tasks that ShutdownNow dropped before they could run
+var ErrPoolClosed = errors.New("worker pool is shut down")
+
+// errNotQueued fails a task's Future if Submit gave up after taking its place in
+// SubmissionOrder, but before queueing it, so the collector knows to skip it
+var errNotQueued = errors.New("task was never queued")
+
+// PanicError is the error a task returns if it panicked. The pool recovers, so one bad
+// task doesn't take down the whole program
+type PanicError struct {
+	Value any    // what the task panicked with
+	Stack []byte // where it panicked
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("task panicked: %v", e.Value)
+}
+
+// ResultOrder is whether, and in what order, a pool sends results to Results
+type ResultOrder int
+
+const (
+	// NoResults means results are only available from the Futures Submit returns
+	NoResults ResultOrder = iota
+	// CompletionOrder sends results to Results as tasks finish
+	CompletionOrder
+	// SubmissionOrder sends results to Results in the order tasks were submitted. A
+	// slow task holds up the results behind it. Submits made at the same time, from
+	// different goroutines, are ordered by whichever got its turn first
+	SubmissionOrder
+)
+
+// Options configures a WorkerPool
+type Options struct {
+	Workers     int           // how many workers are always running, at least 1
+	MaxWorkers  int           // if more than Workers, extra workers start when all are busy, up to this many
+	IdleTimeout time.Duration // how long an extra worker waits for a task before exiting, a minute if not set
+	QueueSize   int           // how many tasks can wait for a worker before Submit blocks
+	Results     ResultOrder   // whether to send results to Results, and in what order
+}
+
+// Task is a unit of work for a WorkerPool. ctx is cancelled by ShutdownNow
+type Task[T any] func(ctx context.Context) (T, error)
+
+type job[T any] struct {
+	task    Task[T]
+	promise *future.Promise[T]
+}
+
+// WorkerPool runs tasks on a bounded number of goroutines, so however many tasks are
+// submitted, only so many run at once, e.g. to limit concurrent rss fetches
+type WorkerPool[T any] struct {
+	options Options
+	clock   schedule.Clock
+	ctx     context.Context // passed to tasks, cancelled by ShutdownNow
+	cancel  context.CancelFunc
+	queue   chan job[T]
+
+	submitting sync.RWMutex  // read locked while submitting, write locked while closing the queue
+	stopping   chan struct{} // closed when shutdown starts, so blocked Submits give up
+	stop       sync.Once
+	closed     bool // guarded by submitting
+
+	counts  sync.Mutex // guards workers and idle
+	workers int
+	idle    int
+	wg      sync.WaitGroup
+
+	pending chan *future.Future[T] // futures in submission order, for SubmissionOrder
+	results chan future.Result[T]
+	done    chan struct{} // closed once every worker has exited, and every result is sent
+}
+
+// NewWorkerPool starts a WorkerPool. Extra workers' idle timeouts are measured with
+// clock. If options ask for Results, keep reading them until they're closed, otherwise
+// the workers will eventually block
+func NewWorkerPool[T any](clock schedule.Clock, options Options) *WorkerPool[T] {
+	options.Workers = max(options.Workers, 1)
+	options.MaxWorkers = max(options.MaxWorkers, options.Workers)
+	if options.IdleTimeout <= 0 {
+		// Otherwise extra workers would time out before picking up the task they were
+		// started for
+		options.IdleTimeout = time.Minute
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	p := &WorkerPool[T]{
+		options:  options,
+		clock:    clock,
+		ctx:      ctx,
+		cancel:   cancel,
+		queue:    make(chan job[T], options.QueueSize),
+		stopping: make(chan struct{}),
+		results:  make(chan future.Result[T]),
+		done:     make(chan struct{}),
+	}
+	if options.Results == SubmissionOrder {
+		p.pending = make(chan *future.Future[T], options.QueueSize+options.MaxWorkers)
+	}
+
+	p.counts.Lock()
+	for i := 0; i < options.Workers; i++ {
+		p.startWorker(false)
+	}
+	p.counts.Unlock()
+
+	// With SubmissionOrder, results wait for those submitted before them, like
+	// pipeline.ParallelMap does
+	collected := make(chan struct{})
+	if p.pending != nil {
+		go func() {
+			defer close(collected)
+			defer close(p.results)
+			for f := range p.pending {
+				value, err := f.Await(context.Background())
+				if errors.Is(err, errNotQueued) {
+					continue
+				}
+				p.results <- future.Result[T]{Value: value, Err: err}
+			}
+		}()
+	}
+	go func() {
+		p.wg.Wait()
+		// The workers only exit once the queue is closed, so nothing more can be submitted
+		if p.pending != nil {
+			close(p.pending)
+			<-collected
+		} else {
+			close(p.results)
+		}
+		cancel()
+		close(p.done)
+	}()
+	return p
+}
+
+// startWorker starts a worker. Extra workers exit once they've been idle for the idle
+// timeout. Must be called with counts held
+func (p *WorkerPool[T]) startWorker(extra bool) {
+	p.workers++
+	p.idle++
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		for {
+			var idle <-chan time.Time // nil, and so never fires, for the core workers
+			var timer schedule.Timer
+			if extra {
+				timer = p.clock.NewTimer(p.options.IdleTimeout)
+				idle = timer.C()
+			}
+			select {
+			case j, ok := <-p.queue:
+				if timer != nil {
+					timer.Stop()
+				}
+				if !ok {
+					p.counts.Lock()
+					p.workers--
+					p.idle--
+					p.counts.Unlock()
+					return
+				}
+				p.counts.Lock()
+				p.idle--
+				p.counts.Unlock()
+				p.run(j)
+				p.counts.Lock()
+				p.idle++
+				p.counts.Unlock()
+			case <-idle:
+				p.counts.Lock()
+				p.workers--
+				p.idle--
+				p.counts.Unlock()
+				return
+			}
+		}
+	}()
+}
+
+// run runs a job, recovering if it panics, unless ShutdownNow has dropped it
+func (p *WorkerPool[T]) run(j job[T]) {
+	var value T
+	var err error
+	if p.ctx.Err() != nil {
+		err = ErrPoolClosed
+	} else {
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					err = &PanicError{Value: r, Stack: debug.Stack()}
+				}
+			}()
+			value, err = j.task(p.ctx)
+		}()
+	}
+	j.promise.Resolve(value, err)
+	if p.options.Results == CompletionOrder {
+		p.results <- future.Result[T]{Value: value, Err: err}
+	}
+}
+
+// Submit queues a task, blocking if the queue is full, and returns a Future for its
+// result. Returns ErrPoolClosed if the pool is shutting down, or ctx.Err() if ctx is
+// done before there's room in the queue. With SubmissionOrder, it also blocks while
+// too many results are waiting to be read from Results
+func (p *WorkerPool[T]) Submit(ctx context.Context, task Task[T]) (*future.Future[T], error) {
+	// Submitters only share a read lock, so one waiting for room in the queue doesn't
+	// hold up another whose ctx is done. close takes the write lock, once stopping has
+	// told any waiting submitters to give up
+	p.submitting.RLock()
+	defer p.submitting.RUnlock()
+	if p.closed {
+		return nil, ErrPoolClosed
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	// If everyone's busy, and we're allowed, start another worker
+	p.counts.Lock()
+	if p.idle == 0 && p.workers < p.options.MaxWorkers {
+		p.startWorker(true)
+	}
+	p.counts.Unlock()
+
+	var zero T
+	j := job[T]{task, future.NewPromise[T]()}
+	if p.pending != nil {
+		// Take our place in the order before queueing, so we never queue a task whose
+		// result can't be collected
+		select {
+		case p.pending <- j.promise.Future():
+		case <-p.stopping:
+			return nil, ErrPoolClosed
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	select {
+	case p.queue <- j:
+		return j.promise.Future(), nil
+	case <-p.stopping:
+		j.promise.Resolve(zero, errNotQueued)
+		return nil, ErrPoolClosed
+	case <-ctx.Done():
+		j.promise.Resolve(zero, errNotQueued)
+		return nil, ctx.Err()
+	}
+}
+
+// Results is the channel results are sent to, if options ask for them. It's closed
+// once the pool has shut down
+func (p *WorkerPool[T]) Results() <-chan future.Result[T] {
+	return p.results
+}
+
+// Shutdown stops accepting tasks, then waits for the queued and running ones to
+// finish. Returns ctx.Err() if ctx is done first, but the pool keeps draining
+func (p *WorkerPool[T]) Shutdown(ctx context.Context) error {
+	p.close()
+	select {
+	case <-p.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// ShutdownNow stops accepting tasks, cancels the ctx of the running ones, and drops
+// the queued ones, which fail with ErrPoolClosed. Then it waits for the running tasks
+// to return, so they should watch ctx
+func (p *WorkerPool[T]) ShutdownNow() {
+	p.cancel()
+	p.close()
+	<-p.done
+}
+
+// close stops Submit accepting tasks, and closes the queue, so workers exit once it's
+// empty
+func (p *WorkerPool[T]) close() {
+	p.stop.Do(func() {
+		close(p.stopping)
+		p.submitting.Lock()
+		defer p.submitting.Unlock()
+		p.closed = true
+		close(p.queue)
+	})
+}
@@ -0,0 +1,313 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/yashap/concurrency/future"
+	"github.com/yashap/concurrency/schedule"
+)
+
+// blocking returns a task that says when it starts, then waits to be released
+func blocking(v int, started chan<- int, release <-chan struct{}) Task[int] {
+	return func(ctx context.Context) (int, error) {
+		started <- v
+		select {
+		case <-release:
+			return v, nil
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		}
+	}
+}
+
+func newPool(options Options) *WorkerPool[int] {
+	return NewWorkerPool[int](schedule.NewFakeClock(time.Now()), options)
+}
+
+func TestSubmit(t *testing.T) {
+	ctx := context.Background()
+	p := newPool(Options{Workers: 2})
+	var futures []*future.Future[int]
+	for i := 0; i < 5; i++ {
+		square := i * i
+		f, err := p.Submit(ctx, func(context.Context) (int, error) { return square, nil })
+		if err != nil {
+			t.Fatalf("Expected no error, but got %v", err)
+		}
+		futures = append(futures, f)
+	}
+	for i, f := range futures {
+		if v, err := f.Await(ctx); err != nil || v != i*i {
+			t.Errorf("Expected %d, but got %d and error %v", i*i, v, err)
+		}
+	}
+	if err := p.Shutdown(ctx); err != nil {
+		t.Errorf("Expected no error, but got %v", err)
+	}
+}
+
+func TestBounded(t *testing.T) {
+	ctx := context.Background()
+	p := newPool(Options{Workers: 2, QueueSize: 2})
+	started := make(chan int, 4)
+	release := make(chan struct{})
+	for i := 0; i < 4; i++ {
+		if _, err := p.Submit(ctx, blocking(i, started, release)); err != nil {
+			t.Fatalf("Expected no error, but got %v", err)
+		}
+	}
+	<-started
+	<-started
+
+	// The queue is full, and only 2 are running
+	full, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	if _, err := p.Submit(full, blocking(4, started, release)); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected %v submitting to a full queue, but got %v", context.DeadlineExceeded, err)
+	}
+	if len(started) != 0 {
+		t.Errorf("Expected only 2 tasks to be running, but %d more started", len(started))
+	}
+
+	close(release)
+	p.Shutdown(ctx)
+	if len(started) != 2 {
+		t.Errorf("Expected the 2 queued tasks to run, but %d did", len(started))
+	}
+}
+
+func TestElastic(t *testing.T) {
+	ctx := context.Background()
+	clock := schedule.NewFakeClock(time.Now())
+	p := NewWorkerPool[int](clock, Options{Workers: 1, MaxWorkers: 3, IdleTimeout: time.Second})
+	workers := func() int {
+		p.counts.Lock()
+		defer p.counts.Unlock()
+		return p.workers
+	}
+
+	// Each task keeps a worker busy, so more start, up to the max
+	started := make(chan int, 3)
+	release := make(chan struct{})
+	for i := 0; i < 3; i++ {
+		p.Submit(ctx, blocking(i, started, release))
+		<-started
+	}
+	if n := workers(); n != 3 {
+		t.Errorf("Expected 3 workers, was %d", n)
+	}
+
+	// Once they're idle, the extra workers time out
+	close(release)
+	clock.BlockUntil(2)
+	clock.Advance(time.Second)
+	deadline := time.Now().Add(time.Second)
+	for workers() != 1 {
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected the extra workers to time out, but there are still %d", workers())
+		}
+		time.Sleep(time.Millisecond)
+	}
+	p.Shutdown(ctx)
+}
+
+func TestElasticWithoutIdleTimeout(t *testing.T) {
+	ctx := context.Background()
+	p := newPool(Options{Workers: 1, MaxWorkers: 2})
+	started := make(chan int, 2)
+	release := make(chan struct{})
+	defer close(release)
+
+	// The extra worker started for the second task must stick around long enough to run it
+	for i := 0; i < 2; i++ {
+		p.Submit(ctx, blocking(i, started, release))
+	}
+	for i := 0; i < 2; i++ {
+		select {
+		case <-started:
+		case <-time.After(time.Second):
+			t.Fatalf("Expected both tasks to start, but only %d did", i)
+		}
+	}
+}
+
+func TestSubmitCancelledWhileAnotherWaits(t *testing.T) {
+	ctx := context.Background()
+	p := newPool(Options{Workers: 1})
+	started := make(chan int, 2)
+	release := make(chan struct{})
+	p.Submit(ctx, blocking(0, started, release))
+	<-started
+
+	// The only worker is busy, and there's no queue, so this waits for room
+	submitting := make(chan struct{})
+	waited := make(chan error)
+	go func() {
+		close(submitting)
+		_, err := p.Submit(ctx, blocking(1, started, release))
+		waited <- err
+	}()
+	<-submitting
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	errs := make(chan error)
+	go func() {
+		_, err := p.Submit(cancelled, blocking(2, started, release))
+		errs <- err
+	}()
+	select {
+	case err := <-errs:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("Expected %v, but got %v", context.Canceled, err)
+		}
+	case <-time.After(time.Second):
+		t.Errorf("Expected a cancelled Submit to return, rather than wait behind another")
+	}
+
+	// The other one was waiting all along, and gets in once there's room
+	close(release)
+	if err := <-waited; err != nil {
+		t.Errorf("Expected no error, but got %v", err)
+	}
+	p.Shutdown(ctx)
+}
+
+func TestSubmissionOrderUnread(t *testing.T) {
+	ctx := context.Background()
+	p := newPool(Options{Workers: 1, Results: SubmissionOrder})
+
+	// Nobody's reading Results, so eventually there's no room for any more, and Submit
+	// waits, but only until ctx is done
+	full, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	submitted := 0
+	errs := make(chan error)
+	go func() {
+		for {
+			if _, err := p.Submit(full, func(context.Context) (int, error) { return 0, nil }); err != nil {
+				errs <- err
+				return
+			}
+			submitted++
+		}
+	}()
+	select {
+	case err := <-errs:
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Expected %v, but got %v", context.DeadlineExceeded, err)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Expected Submit to give up once ctx was done")
+	}
+
+	// Only the tasks that were submitted have results
+	go p.Shutdown(ctx)
+	received := 0
+	for r := range p.Results() {
+		if r.Err != nil {
+			t.Errorf("Expected no error, but got %v", r.Err)
+		}
+		received++
+	}
+	if received != submitted {
+		t.Errorf("Expected %d results, got %d", submitted, received)
+	}
+}
+
+func TestPanic(t *testing.T) {
+	ctx := context.Background()
+	p := newPool(Options{})
+	f, _ := p.Submit(ctx, func(context.Context) (int, error) { panic("oops") })
+	var panicErr *PanicError
+	if _, err := f.Await(ctx); !errors.As(err, &panicErr) || panicErr.Value != "oops" {
+		t.Errorf("Expected a PanicError with value oops, but got %v", err)
+	}
+
+	// The worker survived
+	f, _ = p.Submit(ctx, func(context.Context) (int, error) { return 1, nil })
+	if v, err := f.Await(ctx); err != nil || v != 1 {
+		t.Errorf("Expected 1, but got %d and error %v", v, err)
+	}
+	p.Shutdown(ctx)
+}
+
+func TestSubmissionOrder(t *testing.T) {
+	ctx := context.Background()
+	n := 4
+	p := newPool(Options{Workers: n, Results: SubmissionOrder})
+	started := make(chan int, n)
+	release := make([]chan struct{}, n)
+	for i := range release {
+		release[i] = make(chan struct{})
+		p.Submit(ctx, blocking(i, started, release[i]))
+	}
+
+	// Once all are running at once, let them finish in reverse order
+	for i := 0; i < n; i++ {
+		<-started
+	}
+	for i := n - 1; i >= 0; i-- {
+		close(release[i])
+	}
+	go p.Shutdown(ctx)
+
+	var received []int
+	for r := range p.Results() {
+		received = append(received, r.Value)
+	}
+	if expected := []int{0, 1, 2, 3}; !reflect.DeepEqual(received, expected) {
+		t.Errorf("Expected %v, got %v", expected, received)
+	}
+}
+
+func TestShutdown(t *testing.T) {
+	ctx := context.Background()
+	p := newPool(Options{Workers: 1, QueueSize: 1, Results: CompletionOrder})
+	started := make(chan int, 2)
+	release := make(chan struct{})
+	p.Submit(ctx, blocking(0, started, release))
+	p.Submit(ctx, blocking(1, started, release))
+	<-started
+
+	shutdown := make(chan error)
+	go func() { shutdown <- p.Shutdown(ctx) }()
+	close(release)
+
+	// The queued task still runs
+	var received []int
+	for r := range p.Results() {
+		received = append(received, r.Value)
+	}
+	if expected := []int{0, 1}; !reflect.DeepEqual(received, expected) {
+		t.Errorf("Expected %v, got %v", expected, received)
+	}
+	if err := <-shutdown; err != nil {
+		t.Errorf("Expected no error, but got %v", err)
+	}
+	if _, err := p.Submit(ctx, blocking(2, started, release)); !errors.Is(err, ErrPoolClosed) {
+		t.Errorf("Expected %v, but got %v", ErrPoolClosed, err)
+	}
+}
+
+func TestShutdownNow(t *testing.T) {
+	ctx := context.Background()
+	p := newPool(Options{Workers: 1, QueueSize: 1})
+	started := make(chan int, 2)
+	never := make(chan struct{})
+	running, _ := p.Submit(ctx, blocking(0, started, never))
+	queued, _ := p.Submit(ctx, blocking(1, started, never))
+	<-started
+
+	p.ShutdownNow()
+	if _, err := running.Await(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected the running task to be cancelled, but got %v", err)
+	}
+	if _, err := queued.Await(ctx); !errors.Is(err, ErrPoolClosed) {
+		t.Errorf("Expected the queued task to be dropped with %v, but got %v", ErrPoolClosed, err)
+	}
+}
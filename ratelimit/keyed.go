@@ -0,0 +1,84 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/yashap/concurrency/schedule"
+)
+
+// Keyed keeps a separate Limiter for each key, e.g. one per feed host, so one busy
+// key can't use up the permits of another. Keys that haven't been used for a while
+// are forgotten, so memory doesn't grow forever as keys come and go
+type Keyed struct {
+	clock      schedule.Clock
+	idle       time.Duration
+	newLimiter func() Limiter
+
+	mu        sync.Mutex
+	limiters  map[string]*keyedLimiter
+	lastSweep time.Time
+}
+
+type keyedLimiter struct {
+	limiter  Limiter
+	lastUsed time.Time
+}
+
+// NewKeyed creates a Keyed limiter, which calls newLimiter the first time it sees
+// a key. Keys unused for longer than idle are evicted, so idle should be longer than
+// it takes a limiter to recover fully (e.g. refill a TokenBucket), otherwise eviction
+// hands out a fresh limiter early. Time is measured with clock
+func NewKeyed(clock schedule.Clock, idle time.Duration, newLimiter func() Limiter) *Keyed {
+	return &Keyed{
+		clock:      clock,
+		idle:       idle,
+		newLimiter: newLimiter,
+		limiters:   make(map[string]*keyedLimiter),
+		lastSweep:  clock.Now(),
+	}
+}
+
+// Get returns the Limiter for a key, creating it if needed
+func (k *Keyed) Get(key string) Limiter {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	now := k.clock.Now()
+
+	// Rather than running a goroutine to evict idle keys, sweep them up as we go,
+	// at most once per idle period
+	if now.Sub(k.lastSweep) >= k.idle {
+		for key, l := range k.limiters {
+			if now.Sub(l.lastUsed) >= k.idle {
+				delete(k.limiters, key)
+			}
+		}
+		k.lastSweep = now
+	}
+
+	l, ok := k.limiters[key]
+	if !ok {
+		l = &keyedLimiter{limiter: k.newLimiter()}
+		k.limiters[key] = l
+	}
+	l.lastUsed = now
+	return l.limiter
+}
+
+// Allow takes a permit for a key if one is available right now
+func (k *Keyed) Allow(key string) bool {
+	return k.Get(key).Allow()
+}
+
+// Wait blocks until a permit is available for a key, then takes it
+func (k *Keyed) Wait(ctx context.Context, key string) error {
+	return k.Get(key).Wait(ctx)
+}
+
+// Len returns how many keys are being tracked
+func (k *Keyed) Len() int {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return len(k.limiters)
+}
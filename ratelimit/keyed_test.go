@@ -0,0 +1,38 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/yashap/concurrency/schedule"
+)
+
+func TestKeyed(t *testing.T) {
+	clock := schedule.NewFakeClock(time.Now())
+	k := NewKeyed(clock, time.Minute, func() Limiter {
+		return NewTokenBucket(clock, time.Second, 1)
+	})
+
+	// Each key has its own limit
+	if !k.Allow("a.example.com") || !k.Allow("b.example.com") {
+		t.Errorf("Expected the first permit for each key to be allowed")
+	}
+	if k.Allow("a.example.com") {
+		t.Errorf("Expected the second permit for a key to be limited")
+	}
+	if k.Len() != 2 {
+		t.Errorf("Expected 2 keys to be tracked, was %d", k.Len())
+	}
+
+	// Keys that are still in use are kept, idle ones are evicted
+	clock.Advance(30 * time.Second)
+	k.Allow("a.example.com")
+	clock.Advance(30 * time.Second)
+	k.Allow("c.example.com")
+	if k.Len() != 2 {
+		t.Errorf("Expected the idle key to be evicted, leaving 2 keys, was %d", k.Len())
+	}
+	if k.Get("a.example.com") != k.Get("a.example.com") {
+		t.Errorf("Expected the same limiter to be returned for the same key")
+	}
+}
@@ -0,0 +1,78 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/yashap/concurrency/schedule"
+)
+
+// LeakyBucket is a Limiter that lets permits through at a steady rate of one per
+// interval, like water leaking from a hole in a bucket. Permits that arrive faster
+// than that queue up in the bucket, up to capacity, after which they're refused.
+// Unlike a TokenBucket, it never allows bursts: permits are always spaced out
+type LeakyBucket struct {
+	clock    schedule.Clock
+	interval time.Duration
+	capacity int
+
+	mu   sync.Mutex
+	next time.Time // when the next permit can go
+}
+
+// NewLeakyBucket creates an empty LeakyBucket. Time is measured with clock
+func NewLeakyBucket(clock schedule.Clock, interval time.Duration, capacity int) *LeakyBucket {
+	return &LeakyBucket{clock: clock, interval: interval, capacity: capacity}
+}
+
+// Allow takes a permit if one can go right now, i.e. the bucket is empty
+func (b *LeakyBucket) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	now := b.clock.Now()
+	if b.next.After(now) {
+		return false
+	}
+	b.next = now.Add(b.interval)
+	return true
+}
+
+// Reserve joins the queue in the bucket, and says how long until the permit can go.
+// It's not OK if the queue is already at capacity
+func (b *LeakyBucket) Reserve() Reservation {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	now := b.clock.Now()
+	if b.next.Before(now) {
+		b.next = now
+	}
+	delay := b.next.Sub(now)
+	// How many permits are already waiting for their turn, ahead of this one
+	queued := int((delay + b.interval - 1) / b.interval)
+	if queued > b.capacity {
+		return Reservation{}
+	}
+	at := b.next
+	b.next = b.next.Add(b.interval)
+	return Reservation{OK: true, Delay: delay, cancel: func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		// We can only hand back the slot if nobody has queued up behind it
+		if b.next.Equal(at.Add(b.interval)) {
+			b.next = at
+		}
+	}}
+}
+
+// Wait blocks until a permit can go, then takes it. Returns ErrLimitExceeded if the
+// queue is full, or ctx.Err() if ctx is done first
+func (b *LeakyBucket) Wait(ctx context.Context) error {
+	return wait(ctx, b.clock, b.Reserve)
+}
+
+// Permits sends a permit to the returned channel at the bucket's steady rate, until
+// ctx is done. If the queue is full, it waits an interval for room, and tries again
+func (b *LeakyBucket) Permits(ctx context.Context) <-chan struct{} {
+	return permits(ctx, b.clock, b.interval, b.Wait)
+}
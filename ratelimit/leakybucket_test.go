@@ -0,0 +1,93 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/yashap/concurrency/schedule"
+)
+
+func TestLeakyBucketAllow(t *testing.T) {
+	clock := schedule.NewFakeClock(time.Now())
+	b := NewLeakyBucket(clock, time.Second, 5)
+
+	// No bursts, permits are always spaced out by the interval
+	if !b.Allow() {
+		t.Errorf("Expected the first permit to be allowed")
+	}
+	if b.Allow() {
+		t.Errorf("Expected the second permit to wait for the interval")
+	}
+	clock.Advance(time.Second)
+	if !b.Allow() {
+		t.Errorf("Expected a permit once the interval passed")
+	}
+}
+
+func TestLeakyBucketReserve(t *testing.T) {
+	clock := schedule.NewFakeClock(time.Now())
+	b := NewLeakyBucket(clock, time.Second, 2)
+
+	// The first goes straight away, then 2 can queue up, then the bucket is full
+	for i, expected := range []time.Duration{0, time.Second, 2 * time.Second} {
+		if r := b.Reserve(); !r.OK || r.Delay != expected {
+			t.Errorf("Expected reservation %d to be %v away, got %+v", i, expected, r)
+		}
+	}
+	r := b.Reserve()
+	if r.OK {
+		t.Errorf("Expected a full bucket to refuse a reservation, got %+v", r)
+	}
+	if err := b.Wait(context.Background()); !errors.Is(err, ErrLimitExceeded) {
+		t.Errorf("Expected %v waiting on a full bucket, but got %v", ErrLimitExceeded, err)
+	}
+
+	// As it leaks, there's room again
+	clock.Advance(time.Second)
+	r = b.Reserve()
+	if !r.OK || r.Delay != 2*time.Second {
+		t.Errorf("Expected a reservation 2s away, got %+v", r)
+	}
+
+	// Cancelling the last reservation hands back its slot
+	r.Cancel()
+	if r := b.Reserve(); r.Delay != 2*time.Second {
+		t.Errorf("Expected a reservation 2s away, got %+v", r)
+	}
+}
+
+func TestLeakyBucketPermits(t *testing.T) {
+	clock := schedule.NewFakeClock(time.Now())
+	b := NewLeakyBucket(clock, time.Second, 1)
+	ctx, cancel := context.WithCancel(context.Background())
+	permits := b.Permits(ctx)
+
+	<-permits
+	for i := 0; i < 3; i++ {
+		clock.BlockUntil(1)
+		clock.Advance(time.Second)
+		<-permits
+	}
+
+	cancel()
+	for range permits {
+	}
+}
+
+func TestLeakyBucketPermitsWhenFull(t *testing.T) {
+	clock := schedule.NewFakeClock(time.Now())
+	b := NewLeakyBucket(clock, time.Second, 0)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// The bucket's full, so the first permit has to wait for room, rather than giving up
+	b.Reserve()
+	permits := b.Permits(ctx)
+	clock.BlockUntil(1)
+	clock.Advance(time.Second)
+	if _, ok := <-permits; !ok {
+		t.Errorf("Expected a permit once there was room, but the channel was closed")
+	}
+}
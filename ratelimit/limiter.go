@@ -0,0 +1,93 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/yashap/concurrency/schedule"
+)
+
+// ErrLimitExceeded is returned by Wait when a limiter can't give out a permit at all,
+// e.g. because a LeakyBucket's queue is full
+var ErrLimitExceeded = errors.New("rate limit exceeded")
+
+// Limiter limits how often something can happen. Each time it happens uses up a
+// permit, and the limiter decides how quickly permits are given out
+type Limiter interface {
+	Allow() bool                                 // takes a permit if one is available right now
+	Reserve() Reservation                        // takes a permit that's available now or in future
+	Wait(ctx context.Context) error              // blocks until a permit is available, then takes it
+	Permits(ctx context.Context) <-chan struct{} // a stream of permits, until ctx is done
+}
+
+// Reservation is a permit taken by Limiter.Reserve
+type Reservation struct {
+	OK     bool          // false if the limiter can't give out a permit at all
+	Delay  time.Duration // how long to wait before using the permit
+	cancel func()
+}
+
+// Cancel gives the permit back, if you've decided not to use it
+func (r Reservation) Cancel() {
+	if r.cancel != nil {
+		r.cancel()
+	}
+}
+
+// wait reserves a permit, then waits out its delay, giving it back if ctx is done
+// first. Both kinds of bucket implement Wait with this
+func wait(ctx context.Context, clock schedule.Clock, reserve func() Reservation) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r := reserve()
+	if !r.OK {
+		return ErrLimitExceeded
+	}
+	if r.Delay <= 0 {
+		return nil
+	}
+
+	timer := clock.NewTimer(r.Delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C():
+		return nil
+	case <-ctx.Done():
+		r.Cancel()
+		return ctx.Err()
+	}
+}
+
+// permits sends a permit to a channel every time wait gets one, following the
+// generator pattern, until ctx is done. If the limiter can't give out a permit at
+// the moment, e.g. a LeakyBucket's queue is full, it tries again after retry. Both
+// kinds of bucket implement Permits with this
+func permits(ctx context.Context, clock schedule.Clock, retry time.Duration, wait func(ctx context.Context) error) <-chan struct{} {
+	out := make(chan struct{})
+	go func() {
+		defer close(out)
+		for {
+			if err := wait(ctx); err != nil {
+				if ctx.Err() != nil {
+					return
+				}
+				timer := clock.NewTimer(retry)
+				select {
+				case <-timer.C():
+				case <-ctx.Done():
+					timer.Stop()
+					return
+				}
+				continue
+			}
+			select {
+			case out <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out
+}
@@ -0,0 +1,89 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/yashap/concurrency/schedule"
+)
+
+// TokenBucket is a Limiter that holds up to burst tokens, and adds one every interval.
+// Each permit uses a token, so it allows bursts of up to burst at once, but averages
+// out to one permit per interval
+type TokenBucket struct {
+	clock    schedule.Clock
+	interval time.Duration
+	burst    int
+
+	mu     sync.Mutex
+	tokens float64 // can go negative, when permits are reserved for the future
+	last   time.Time
+}
+
+// NewTokenBucket creates a TokenBucket, starting full. Time is measured with clock
+func NewTokenBucket(clock schedule.Clock, interval time.Duration, burst int) *TokenBucket {
+	return &TokenBucket{
+		clock:    clock,
+		interval: interval,
+		burst:    burst,
+		tokens:   float64(burst),
+		last:     clock.Now(),
+	}
+}
+
+// refill adds the tokens earned since we last looked. Must be called with b locked
+func (b *TokenBucket) refill() {
+	now := b.clock.Now()
+	if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.tokens = min(float64(b.burst), b.tokens+float64(elapsed)/float64(b.interval))
+	}
+	b.last = now
+}
+
+// Allow takes a token if one is available right now
+func (b *TokenBucket) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refill()
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// Reserve takes a token, even if it won't be available until the future, and says
+// how long until it is. It's only not OK if burst is less than 1, in which case
+// there will never be a token
+func (b *TokenBucket) Reserve() Reservation {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.burst < 1 {
+		return Reservation{}
+	}
+	b.refill()
+	b.tokens--
+	var delay time.Duration
+	if b.tokens < 0 {
+		delay = time.Duration(-b.tokens * float64(b.interval))
+	}
+	return Reservation{OK: true, Delay: delay, cancel: func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		b.refill()
+		b.tokens = min(float64(b.burst), b.tokens+1)
+	}}
+}
+
+// Wait blocks until a token is available, then takes it. Returns ctx.Err() if ctx
+// is done first
+func (b *TokenBucket) Wait(ctx context.Context) error {
+	return wait(ctx, b.clock, b.Reserve)
+}
+
+// Permits sends a permit to the returned channel every time a token is available,
+// until ctx is done
+func (b *TokenBucket) Permits(ctx context.Context) <-chan struct{} {
+	return permits(ctx, b.clock, b.interval, b.Wait)
+}
@@ -0,0 +1,118 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/yashap/concurrency/schedule"
+)
+
+func TestTokenBucketAllow(t *testing.T) {
+	clock := schedule.NewFakeClock(time.Now())
+	b := NewTokenBucket(clock, time.Second, 3)
+
+	// Starts full, so allows a burst
+	for i := 0; i < 3; i++ {
+		if !b.Allow() {
+			t.Errorf("Expected permit %d of the burst to be allowed", i)
+		}
+	}
+	if b.Allow() {
+		t.Errorf("Expected no permits once the bucket is empty")
+	}
+
+	// Refills one token per interval
+	clock.Advance(time.Second)
+	if !b.Allow() {
+		t.Errorf("Expected a permit once a token was refilled")
+	}
+	if b.Allow() {
+		t.Errorf("Expected only one token to be refilled")
+	}
+
+	// Never holds more than burst
+	clock.Advance(time.Hour)
+	allowed := 0
+	for b.Allow() {
+		allowed++
+	}
+	if allowed != 3 {
+		t.Errorf("Expected a full bucket to allow 3 permits, allowed %d", allowed)
+	}
+}
+
+func TestTokenBucketReserve(t *testing.T) {
+	clock := schedule.NewFakeClock(time.Now())
+	b := NewTokenBucket(clock, time.Second, 1)
+
+	if r := b.Reserve(); !r.OK || r.Delay != 0 {
+		t.Errorf("Expected an immediate reservation, got %+v", r)
+	}
+	if r := b.Reserve(); !r.OK || r.Delay != time.Second {
+		t.Errorf("Expected a reservation 1s away, got %+v", r)
+	}
+
+	// Cancelling gives the token back, so the next one is only 2s away, rather than 3s
+	r := b.Reserve()
+	r.Cancel()
+	if r := b.Reserve(); r.Delay != 2*time.Second {
+		t.Errorf("Expected a reservation 2s away, got %+v", r)
+	}
+
+	if r := NewTokenBucket(clock, time.Second, 0).Reserve(); r.OK {
+		t.Errorf("Expected a bucket with no burst to never reserve, got %+v", r)
+	}
+}
+
+func TestTokenBucketWait(t *testing.T) {
+	clock := schedule.NewFakeClock(time.Now())
+	b := NewTokenBucket(clock, time.Second, 1)
+	ctx := context.Background()
+
+	if err := b.Wait(ctx); err != nil {
+		t.Errorf("Expected no error, but got %v", err)
+	}
+
+	errs := make(chan error)
+	go func() { errs <- b.Wait(ctx) }()
+	clock.BlockUntil(1)
+	clock.Advance(time.Second)
+	if err := <-errs; err != nil {
+		t.Errorf("Expected no error, but got %v", err)
+	}
+
+	// Giving up on a wait gives the token back
+	cancelled, cancel := context.WithCancel(ctx)
+	go func() { errs <- b.Wait(cancelled) }()
+	clock.BlockUntil(1)
+	cancel()
+	if err := <-errs; !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected %v, but got %v", context.Canceled, err)
+	}
+	clock.Advance(time.Second)
+	if !b.Allow() {
+		t.Errorf("Expected the cancelled wait's token to be available")
+	}
+}
+
+func TestTokenBucketPermits(t *testing.T) {
+	clock := schedule.NewFakeClock(time.Now())
+	b := NewTokenBucket(clock, time.Second, 2)
+	ctx, cancel := context.WithCancel(context.Background())
+	permits := b.Permits(ctx)
+
+	// The burst comes straight away, then one per interval
+	<-permits
+	<-permits
+	for i := 0; i < 3; i++ {
+		clock.BlockUntil(1)
+		clock.Advance(time.Second)
+		<-permits
+	}
+
+	cancel()
+	for range permits {
+	}
+}
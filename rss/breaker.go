@@ -0,0 +1,30 @@
+package rss
+
+import (
+	"github.com/yashap/concurrency/breaker"
+)
+
+// WithBreaker wraps a Fetcher with a circuit breaker, so once a feed host keeps failing,
+// fetches fail fast with breaker.ErrOpen, rather than hitting it again. A Subscription
+// backs off on ErrOpen like any other failure, and the host only sees a probe once the
+// breaker's cool-down is over
+func WithBreaker(fetcher Fetcher, b *breaker.Breaker) Fetcher {
+	return &breakerFetcher{fetcher: fetcher, breaker: b}
+}
+
+// breakerFetcher implements the Fetcher interface
+type breakerFetcher struct {
+	fetcher Fetcher
+	breaker *breaker.Breaker
+}
+
+// Fetch fetches items from the wrapped Fetcher, unless the breaker is open
+func (f *breakerFetcher) Fetch() FetchResult {
+	done, err := f.breaker.Allow()
+	if err != nil {
+		return FetchResult{Err: err}
+	}
+	result := f.fetcher.Fetch()
+	done(result.Err)
+	return result
+}
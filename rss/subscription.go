@@ -2,6 +2,8 @@ package rss
 
 import (
 	"time"
+
+	"github.com/yashap/concurrency/schedule"
 )
 
 // Subscription is a subscription to an RSS feed
@@ -11,10 +13,24 @@ type Subscription interface {
 }
 
 // Subscribe uses a Fetcher to create a Subscription. It will immediately start
-// fetching items from the feed, and sending them to the updates channel
+// fetching items from the feed, and sending them to the updates channel. If a
+// fetch fails, it retries with jittered exponential backoff. The first retry is
+// after 5-10s, and the range doubles with each failure, until it's capped at
+// 2.5-5 minutes
 func Subscribe(fetcher Fetcher) Subscription {
+	return SubscribeWithBackoff(
+		fetcher,
+		schedule.EqualJitter(schedule.ExponentialBackoff(10*time.Second, 2, 5*time.Minute), nil),
+	)
+}
+
+// SubscribeWithBackoff is like Subscribe, but lets you choose how long to wait
+// before retrying when a fetch fails. The backoff is reset whenever a fetch
+// succeeds. Each Subscription needs its own Backoff, they can't be shared
+func SubscribeWithBackoff(fetcher Fetcher, backoff schedule.Backoff) Subscription {
 	s := &sub{
 		fetcher: fetcher,
+		backoff: backoff,
 		updates: make(chan Item),
 		closed:  false,
 		err:     nil,
@@ -27,8 +43,9 @@ func Subscribe(fetcher Fetcher) Subscription {
 // sub implementes the Subscription interface. We make it private, so that you can only
 // construct it with Subscribe(fetcher Fetcher), as the initialization is a bit complex
 type sub struct {
-	fetcher Fetcher   // fetches items
-	updates chan Item // delivers items to the consumer of the Subscription
+	fetcher Fetcher          // fetches items
+	backoff schedule.Backoff // how long to wait before retrying failed fetches
+	updates chan Item        // delivers items to the consumer of the Subscription
 	closed  bool
 	err     error
 	// This `chan chan` enables a request/response style of communication.
@@ -101,9 +118,10 @@ func (s *sub) loop() {
 			err = result.Err
 			next = result.Next
 			if err != nil {
-				next = time.Now().Add(10 * time.Second)
+				next = time.Now().Add(s.backoff.Next())
 				break
 			}
+			s.backoff.Reset() // it's working again, so the next failure starts with a short delay
 			for _, item := range result.Fetched {
 				if !seen[item.GUID] {
 					// We can't just send each `item`` into `s.updates`, could block forever.
@@ -0,0 +1,235 @@
+package schedule
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"time"
+)
+
+// Backoff yields a sequence of delays, e.g. how long to wait between retries. Call
+// Reset once things are working again (e.g. a retry succeeded), to start over from
+// the first delay. Backoffs keep state, and aren't safe for concurrent use, so each
+// retry loop needs its own
+type Backoff interface {
+	Next() time.Duration
+	Reset()
+}
+
+// ConstantBackoff always waits d
+func ConstantBackoff(d time.Duration) Backoff {
+	return &linear{initial: d, max: d}
+}
+
+// LinearBackoff waits initial, then adds step to the delay every time, up to max
+func LinearBackoff(initial, step, max time.Duration) Backoff {
+	return &linear{initial: initial, step: step, max: max}
+}
+
+type linear struct {
+	initial, step, max time.Duration
+	attempt            int
+}
+
+func (b *linear) Next() time.Duration {
+	d := b.initial + time.Duration(b.attempt)*b.step
+	b.attempt++
+	if d > b.max || d < b.initial { // the second check catches overflow
+		return b.max
+	}
+	return d
+}
+
+func (b *linear) Reset() { b.attempt = 0 }
+
+// ExponentialBackoff waits initial, then multiplies the delay by factor every time,
+// up to max
+func ExponentialBackoff(initial time.Duration, factor float64, max time.Duration) Backoff {
+	return &exponential{initial: initial, factor: factor, max: max}
+}
+
+type exponential struct {
+	initial time.Duration
+	factor  float64
+	max     time.Duration
+	attempt int
+}
+
+func (b *exponential) Next() time.Duration {
+	d := float64(b.initial) * math.Pow(b.factor, float64(b.attempt))
+	b.attempt++
+	if d > float64(b.max) {
+		return b.max
+	}
+	return time.Duration(d)
+}
+
+func (b *exponential) Reset() { b.attempt = 0 }
+
+// FibonacciBackoff waits unit, unit, 2 * unit, 3 * unit, 5 * unit, and so on, up to
+// max. It grows more gently than doubling
+func FibonacciBackoff(unit, max time.Duration) Backoff {
+	b := &fibonacci{unit: unit, max: max}
+	b.Reset()
+	return b
+}
+
+type fibonacci struct {
+	unit, max  time.Duration
+	prev, curr time.Duration
+}
+
+func (b *fibonacci) Next() time.Duration {
+	d := b.curr
+	if d >= b.max {
+		return b.max
+	}
+	b.prev, b.curr = b.curr, b.prev+b.curr
+	return d
+}
+
+func (b *fibonacci) Reset() { b.prev, b.curr = 0, b.unit }
+
+// FullJitter waits a random amount between 0 and whatever b would have waited. If
+// lots of clients are retrying against the same server, this spreads them out, so
+// they don't all come back at once. Randomness comes from r, or math/rand's global
+// source if r is nil
+func FullJitter(b Backoff, r *rand.Rand) Backoff {
+	return &jitter{b, r, func(d time.Duration, r *rand.Rand) time.Duration {
+		return randomDuration(r, 0, d)
+	}}
+}
+
+// EqualJitter waits half of whatever b would have waited, plus a random amount up to
+// the other half. It spreads clients out like FullJitter, but never waits too little.
+// Randomness comes from r, or math/rand's global source if r is nil
+func EqualJitter(b Backoff, r *rand.Rand) Backoff {
+	return &jitter{b, r, func(d time.Duration, r *rand.Rand) time.Duration {
+		return d/2 + randomDuration(r, 0, d-d/2)
+	}}
+}
+
+type jitter struct {
+	Backoff
+	r      *rand.Rand
+	jitter func(d time.Duration, r *rand.Rand) time.Duration
+}
+
+func (b *jitter) Next() time.Duration { return b.jitter(b.Backoff.Next(), b.r) }
+
+// DecorrelatedJitter waits a random amount between base and 3 times its previous
+// delay, up to max. The delay grows, but each one depends on the last random value,
+// rather than the attempt number, which spreads clients out further. Randomness comes
+// from r, or math/rand's global source if r is nil
+func DecorrelatedJitter(base, max time.Duration, r *rand.Rand) Backoff {
+	return &decorrelated{base: base, max: max, r: r, prev: base}
+}
+
+type decorrelated struct {
+	base, max, prev time.Duration
+	r               *rand.Rand
+}
+
+func (b *decorrelated) Next() time.Duration {
+	d := randomDuration(b.r, b.base, 3*b.prev)
+	if d > b.max {
+		d = b.max
+	}
+	b.prev = d
+	return d
+}
+
+func (b *decorrelated) Reset() { b.prev = b.base }
+
+// randomDuration returns a random duration in [min, max), or min if the range is empty
+func randomDuration(r *rand.Rand, min, max time.Duration) time.Duration {
+	if max <= min {
+		return min
+	}
+	if r == nil {
+		return min + time.Duration(rand.Int63n(int64(max-min)))
+	}
+	return min + time.Duration(r.Int63n(int64(max-min)))
+}
+
+// BackoffTicker sends the time to its output channel after each of a Backoff's delays
+// in turn, see NewBackoffTicker
+type BackoffTicker struct {
+	*Generator[time.Time]
+	reset chan chan struct{} // Reset asks for a reset, and waits for a reply once it's done
+	done  chan struct{}      // closed once the ticker has stopped
+}
+
+// NewBackoffTicker sends the time to a channel after every delay b yields: first after
+// b.Next(), then after b.Next() again, and so on. Call Reset when things start working
+// again, to start over from the first delay. Time is measured with clock. It runs until
+// ctx is cancelled, or Stop is called, at which point the output is closed
+//
+// A retry loop might look like:
+//
+//	ticker := NewBackoffTicker(ctx, clock, ExponentialBackoff(time.Second, 2, time.Minute))
+//	for range ticker.Output() {
+//		if err := try(); err == nil {
+//			ticker.Reset()
+//		}
+//	}
+func NewBackoffTicker(ctx context.Context, clock Clock, b Backoff) *BackoffTicker {
+	ctx, cancel := context.WithCancel(ctx)
+	output := make(chan time.Time)
+	t := &BackoffTicker{
+		Generator: &Generator[time.Time]{output, cancel},
+		reset:     make(chan chan struct{}),
+		done:      make(chan struct{}),
+	}
+
+	timer := clock.NewTimer(b.Next())
+	go func() {
+		defer close(t.done)
+		defer close(output)
+		defer timer.Stop()
+
+		// b is only ever touched from this goroutine, so it doesn't need to be safe for
+		// concurrent use
+		restart := func(reply chan struct{}) {
+			defer close(reply)
+			b.Reset()
+			if !timer.Stop() {
+				// It may have fired while we were busy, don't let that tick through
+				select {
+				case <-timer.C():
+				default:
+				}
+			}
+			timer.Reset(b.Next())
+		}
+		for {
+			select {
+			case now := <-timer.C():
+				select {
+				case output <- now:
+					timer.Reset(b.Next())
+				case reply := <-t.reset:
+					restart(reply)
+				case <-ctx.Done():
+					return
+				}
+			case reply := <-t.reset:
+				restart(reply)
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return t
+}
+
+// Reset starts the backoff over. Once it returns, the next tick comes after the first
+// delay, counting from now
+func (t *BackoffTicker) Reset() {
+	reply := make(chan struct{})
+	select {
+	case t.reset <- reply:
+		<-reply
+	case <-t.done:
+	}
+}
@@ -0,0 +1,112 @@
+package schedule
+
+import (
+	"context"
+	"math/rand"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// delays returns the next n delays from b
+func delays(b Backoff, n int) []time.Duration {
+	var ds []time.Duration
+	for i := 0; i < n; i++ {
+		ds = append(ds, b.Next())
+	}
+	return ds
+}
+
+func TestBackoff(t *testing.T) {
+	s := time.Second
+	tests := []struct {
+		name     string
+		backoff  Backoff
+		expected []time.Duration
+	}{
+		{"Constant", ConstantBackoff(s), []time.Duration{s, s, s, s}},
+		{"Linear", LinearBackoff(s, 2*s, 6*s), []time.Duration{s, 3 * s, 5 * s, 6 * s, 6 * s}},
+		{"Exponential", ExponentialBackoff(s, 2, 10*s), []time.Duration{s, 2 * s, 4 * s, 8 * s, 10 * s}},
+		{"Fibonacci", FibonacciBackoff(s, 6*s), []time.Duration{s, s, 2 * s, 3 * s, 5 * s, 6 * s, 6 * s}},
+	}
+
+	for _, test := range tests {
+		if actual := delays(test.backoff, len(test.expected)); !reflect.DeepEqual(actual, test.expected) {
+			t.Errorf("Expected %s backoff to yield %v, got %v", test.name, test.expected, actual)
+		}
+
+		// Once reset, it starts over
+		test.backoff.Reset()
+		if actual := delays(test.backoff, len(test.expected)); !reflect.DeepEqual(actual, test.expected) {
+			t.Errorf("Expected reset %s backoff to yield %v, got %v", test.name, test.expected, actual)
+		}
+	}
+}
+
+func TestBackoffJitter(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	n := 100
+
+	for i, d := range delays(FullJitter(ConstantBackoff(time.Second), r), n) {
+		if d < 0 || d >= time.Second {
+			t.Errorf("Expected full jitter delay %d to be in [0s, 1s), was %v", i, d)
+		}
+	}
+
+	for i, d := range delays(EqualJitter(ConstantBackoff(time.Second), r), n) {
+		if d < time.Second/2 || d >= time.Second {
+			t.Errorf("Expected equal jitter delay %d to be in [0.5s, 1s), was %v", i, d)
+		}
+	}
+
+	prev := time.Second
+	for i, d := range delays(DecorrelatedJitter(time.Second, time.Minute, r), n) {
+		if d < time.Second || d > time.Minute || d > 3*prev {
+			t.Errorf("Expected decorrelated jitter delay %d to be in [1s, min(1m, %v)], was %v", i, 3*prev, d)
+		}
+		prev = d
+	}
+
+	// Jittered delays differ from one another, so clients spread out
+	ds := delays(FullJitter(ConstantBackoff(time.Second), r), n)
+	same := 0
+	for _, d := range ds {
+		if d == ds[0] {
+			same++
+		}
+	}
+	if same == n {
+		t.Errorf("Expected jittered delays to vary, but were all %v", ds[0])
+	}
+}
+
+func TestBackoffTicker(t *testing.T) {
+	start := time.Now()
+	clock := NewFakeClock(start)
+	ticker := NewBackoffTicker(context.Background(), clock, ExponentialBackoff(time.Second, 2, time.Minute))
+	defer ticker.Stop()
+
+	// Ticks come after 1s, then 2s more, then 4s more
+	elapsed := time.Duration(0)
+	for _, d := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second} {
+		clock.BlockUntil(1)
+		clock.Advance(d)
+		elapsed += d
+		if tick := <-ticker.Output(); !tick.Equal(start.Add(elapsed)) {
+			t.Errorf("Expected tick at %v, got %v", start.Add(elapsed), tick)
+		}
+	}
+
+	// Once reset, the next tick is back to 1s away
+	ticker.Reset()
+	clock.Advance(time.Second)
+	elapsed += time.Second
+	if tick := <-ticker.Output(); !tick.Equal(start.Add(elapsed)) {
+		t.Errorf("Expected tick at %v after reset, got %v", start.Add(elapsed), tick)
+	}
+
+	ticker.Stop()
+	ticker.Reset() // doesn't block once stopped
+	for range ticker.Output() {
+	}
+}
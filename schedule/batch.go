@@ -0,0 +1,67 @@
+package schedule
+
+import (
+	"context"
+	"time"
+)
+
+// Batch groups values from a channel into slices, sending a batch once it has size
+// values, or once maxLatency has passed since the first value in it arrived,
+// whichever comes first. That way values are grouped up when they're arriving
+// quickly, but a trickle of values isn't held up for long. A maxLatency of 0 or less
+// means batches are only sent once they're full. Time is measured with clock
+//
+// When the input is closed, any partial batch is sent, then the output is closed.
+// If ctx is cancelled, the output is closed straight away, and any partial batch is
+// dropped
+func Batch[T any](ctx context.Context, clock Clock, c <-chan T, size int, maxLatency time.Duration) <-chan []T {
+	out := make(chan []T)
+	go func() {
+		defer close(out)
+		var batch []T
+		var timer Timer
+		var flushAt <-chan time.Time // nil, and so never fires, unless a batch is waiting
+
+		// flush sends the batch, returning false if ctx was cancelled first
+		flush := func() bool {
+			if timer != nil {
+				timer.Stop()
+				timer, flushAt = nil, nil
+			}
+			select {
+			case out <- batch:
+				batch = nil
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		for {
+			select {
+			case v, ok := <-c:
+				if !ok {
+					if len(batch) > 0 {
+						flush()
+					}
+					return
+				}
+				batch = append(batch, v)
+				if len(batch) == 1 && maxLatency > 0 {
+					timer = clock.NewTimer(maxLatency)
+					flushAt = timer.C()
+				}
+				if len(batch) >= size && !flush() {
+					return
+				}
+			case <-flushAt:
+				if !flush() {
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out
+}
@@ -0,0 +1,73 @@
+package schedule
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestBatchBySize(t *testing.T) {
+	input := make(chan int)
+	go func() {
+		for i := 0; i < 7; i++ {
+			input <- i
+		}
+		close(input)
+	}()
+
+	var batches [][]int
+	for batch := range Batch(context.Background(), NewFakeClock(time.Now()), input, 3, time.Second) {
+		batches = append(batches, batch)
+	}
+
+	// The final partial batch is flushed when the input closes
+	expected := [][]int{{0, 1, 2}, {3, 4, 5}, {6}}
+	if !reflect.DeepEqual(batches, expected) {
+		t.Errorf("Expected batches %v, got %v", expected, batches)
+	}
+}
+
+func TestBatchByLatency(t *testing.T) {
+	clock := NewFakeClock(time.Now())
+	maxLatency := 100 * time.Millisecond
+	input := make(chan int)
+	c := Batch(context.Background(), clock, input, 10, maxLatency)
+
+	input <- 1
+	clock.BlockUntil(1) // the batch's timer has started
+	clock.Advance(maxLatency / 2)
+	input <- 2
+
+	// The latency counts from the first value in the batch, not the latest
+	clock.Advance(maxLatency / 2)
+	if batch := <-c; !reflect.DeepEqual(batch, []int{1, 2}) {
+		t.Errorf("Expected batch [1 2], got %v", batch)
+	}
+
+	// A new batch starts its own timer
+	input <- 3
+	clock.BlockUntil(1)
+	clock.Advance(maxLatency - time.Millisecond)
+	select {
+	case batch := <-c:
+		t.Errorf("Expected no batch before max latency, got %v", batch)
+	default:
+	}
+	clock.Advance(time.Millisecond)
+	if batch := <-c; !reflect.DeepEqual(batch, []int{3}) {
+		t.Errorf("Expected batch [3], got %v", batch)
+	}
+}
+
+func TestBatchCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	input := make(chan int)
+	c := Batch(ctx, NewFakeClock(time.Now()), input, 10, 0)
+
+	input <- 1
+	cancel()
+	if batch, ok := <-c; ok {
+		t.Errorf("Expected output channel to be closed, but delivered %v", batch)
+	}
+}
@@ -0,0 +1,54 @@
+package schedule
+
+import (
+	"time"
+)
+
+// Clock is everything schedule needs from the time package. Passing one in, rather
+// than calling time.Sleep and friends directly, lets tests swap in a FakeClock, and
+// control exactly when time passes
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+	NewTimer(d time.Duration) Timer
+	NewTicker(d time.Duration) Ticker
+	Sleep(d time.Duration)
+}
+
+// Timer is like a time.Timer, but its channel is accessed through a method, so it can
+// be part of an interface
+type Timer interface {
+	C() <-chan time.Time
+	Stop() bool
+	Reset(d time.Duration) bool
+}
+
+// Ticker is like a time.Ticker, but its channel is accessed through a method, so it
+// can be part of an interface
+type Ticker interface {
+	C() <-chan time.Time
+	Stop()
+	Reset(d time.Duration)
+}
+
+// NewRealClock creates a Clock backed by the time package
+func NewRealClock() Clock {
+	return realClock{}
+}
+
+// realClock implements the Clock interface, by delegating to the time package
+type realClock struct{}
+
+func (realClock) Now() time.Time                         { return time.Now() }
+func (realClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+func (realClock) NewTimer(d time.Duration) Timer         { return realTimer{time.NewTimer(d)} }
+func (realClock) NewTicker(d time.Duration) Ticker       { return realTicker{time.NewTicker(d)} }
+func (realClock) Sleep(d time.Duration)                  { time.Sleep(d) }
+
+type realTimer struct{ *time.Timer }
+
+func (t realTimer) C() <-chan time.Time { return t.Timer.C }
+
+type realTicker struct{ *time.Ticker }
+
+func (t realTicker) C() <-chan time.Time { return t.Ticker.C }
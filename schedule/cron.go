@@ -0,0 +1,355 @@
+package schedule
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CronSchedule is a parsed cron expression, see ParseCron
+type CronSchedule struct {
+	second, minute, hour, dom, month, dow uint64 // bit n is set if the field matches n
+	domStar, dowStar                      bool   // whether the day fields were left as *
+	location                              *time.Location
+}
+
+// field describes one of the fields in a cron expression
+type field struct {
+	name     string
+	min, max int
+	names    map[string]int // e.g. "jan" for months, "mon" for weekdays
+}
+
+var (
+	seconds = field{"second", 0, 59, nil}
+	minutes = field{"minute", 0, 59, nil}
+	hours   = field{"hour", 0, 23, nil}
+	doms    = field{"day of month", 1, 31, nil}
+	months  = field{"month", 1, 12, map[string]int{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}}
+	dows = field{"day of week", 0, 7, map[string]int{ // 0 and 7 are both Sunday
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}}
+)
+
+// macros are shorthands for common expressions
+var macros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// ParseCron parses a standard cron expression. It may have:
+//  * 5 fields (minute, hour, day of month, month, day of week)
+//  * 6 fields, with seconds in front of the standard 5
+//  * one of the macros @yearly, @annually, @monthly, @weekly, @daily, @midnight
+//    or @hourly
+//
+// Each field can be *, a number, a range (1-5), a list (1,3,5), or any of those
+// with a step (*/15, 1-30/2). Months and weekdays can be given by name (JAN, MON).
+// Like most crons, if both day of month and day of week are restricted, a day
+// matches if either one does. The expression is evaluated in the local time zone,
+// unless it starts with CRON_TZ=<zone> or TZ=<zone>, e.g.
+// "CRON_TZ=America/New_York 0 9 * * MON-FRI" for every weekday at 09:00 in New York
+func ParseCron(expr string) (*CronSchedule, error) {
+	s := &CronSchedule{location: time.Local}
+
+	expr = strings.TrimSpace(expr)
+	if strings.HasPrefix(expr, "CRON_TZ=") || strings.HasPrefix(expr, "TZ=") {
+		zone, rest, _ := strings.Cut(expr, " ")
+		_, name, _ := strings.Cut(zone, "=")
+		location, err := time.LoadLocation(name)
+		if err != nil {
+			return nil, fmt.Errorf("cron: invalid time zone %q: %v", name, err)
+		}
+		s.location = location
+		expr = strings.TrimSpace(rest)
+	}
+	if strings.HasPrefix(expr, "@") {
+		macro, ok := macros[strings.ToLower(expr)]
+		if !ok {
+			return nil, fmt.Errorf("cron: unknown macro %q", expr)
+		}
+		expr = macro
+	}
+
+	fields := strings.Fields(expr)
+	switch len(fields) {
+	case 5:
+		fields = append([]string{"0"}, fields...)
+	case 6:
+	default:
+		return nil, fmt.Errorf("cron: expected 5 or 6 fields, found %d in %q", len(fields), expr)
+	}
+
+	var err error
+	for i, f := range []struct {
+		bits  *uint64
+		field field
+	}{
+		{&s.second, seconds},
+		{&s.minute, minutes},
+		{&s.hour, hours},
+		{&s.dom, doms},
+		{&s.month, months},
+		{&s.dow, dows},
+	} {
+		if *f.bits, err = parseField(fields[i], f.field); err != nil {
+			return nil, err
+		}
+	}
+	if s.dow&(1<<7) != 0 {
+		s.dow |= 1 // 7 is another way of saying Sunday
+	}
+	s.domStar = strings.HasPrefix(fields[3], "*") || fields[3] == "?"
+	s.dowStar = strings.HasPrefix(fields[5], "*") || fields[5] == "?"
+	return s, nil
+}
+
+// parseField parses a single comma separated field, returning the bits it matches
+func parseField(expr string, f field) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(expr, ",") {
+		rangeExpr, stepExpr, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			var err error
+			if step, err = strconv.Atoi(stepExpr); err != nil || step <= 0 {
+				return 0, fmt.Errorf("cron: invalid step %q in %s field", stepExpr, f.name)
+			}
+		}
+
+		var low, high int
+		switch {
+		case rangeExpr == "*" || rangeExpr == "?":
+			low, high = f.min, f.max
+		case strings.Contains(rangeExpr, "-"):
+			lowExpr, highExpr, _ := strings.Cut(rangeExpr, "-")
+			var err error
+			if low, err = parseValue(lowExpr, f); err != nil {
+				return 0, err
+			}
+			if high, err = parseValue(highExpr, f); err != nil {
+				return 0, err
+			}
+			if f.name == dows.name && high == 0 && low > 0 {
+				high = 7 // SAT-SUN ends on Sunday, so count it as 7, like 6-7
+			}
+		default:
+			var err error
+			if low, err = parseValue(rangeExpr, f); err != nil {
+				return 0, err
+			}
+			high = low
+			if hasStep {
+				high = f.max // 5/15 means starting at 5, every 15
+			}
+		}
+		if low > high {
+			return 0, fmt.Errorf("cron: invalid range %q in %s field", rangeExpr, f.name)
+		}
+
+		for v := low; v <= high; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+// parseValue parses a single number or name, checking it's in range for the field
+func parseValue(expr string, f field) (int, error) {
+	if v, ok := f.names[strings.ToLower(expr)]; ok {
+		return v, nil
+	}
+	v, err := strconv.Atoi(expr)
+	if err != nil {
+		return 0, fmt.Errorf("cron: invalid value %q in %s field", expr, f.name)
+	}
+	if v < f.min || v > f.max {
+		return 0, fmt.Errorf("cron: %d is out of range [%d, %d] for %s field", v, f.min, f.max, f.name)
+	}
+	return v, nil
+}
+
+// Next returns the first time after t that the schedule fires, or the zero time if it
+// never fires in the next 5 years (e.g. "0 0 30 2 *", the 30th of February)
+func (s *CronSchedule) Next(t time.Time) time.Time {
+	original := t.Location()
+	t = t.In(s.location)
+
+	// Start at the next whole second
+	t = t.Add(time.Second - time.Duration(t.Nanosecond()))
+	yearLimit := t.Year() + 5
+
+	// Starting from the biggest field, find the next value that matches, resetting all
+	// smaller fields to their minimum whenever we move forward. If a field wraps around
+	// (e.g. hours go from 23 to 0) the bigger fields might not match anymore, so start
+	// over from the top
+	added := false
+wrap:
+	if t.Year() > yearLimit {
+		return time.Time{}
+	}
+
+	for s.month&(1<<uint(t.Month())) == 0 {
+		if !added {
+			added = true
+			t = time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, s.location)
+		}
+		t = t.AddDate(0, 1, 0)
+		if t.Month() == time.January {
+			goto wrap
+		}
+	}
+
+	for !s.dayMatches(t) {
+		if !added {
+			added = true
+			t = time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, s.location)
+		}
+		t = t.AddDate(0, 0, 1)
+		// Across a daylight savings change, midnight can move, so put it back
+		if t.Hour() != 0 {
+			if t.Hour() > 12 {
+				t = t.Add(time.Duration(24-t.Hour()) * time.Hour)
+			} else {
+				t = t.Add(time.Duration(-t.Hour()) * time.Hour)
+			}
+		}
+		if t.Day() == 1 {
+			goto wrap
+		}
+	}
+
+	for s.hour&(1<<uint(t.Hour())) == 0 {
+		if !added {
+			added = true
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, s.location)
+		}
+		t = t.Add(time.Hour)
+		if t.Hour() == 0 {
+			goto wrap
+		}
+	}
+
+	for s.minute&(1<<uint(t.Minute())) == 0 {
+		if !added {
+			added = true
+			t = t.Truncate(time.Minute)
+		}
+		t = t.Add(time.Minute)
+		if t.Minute() == 0 {
+			goto wrap
+		}
+	}
+
+	for s.second&(1<<uint(t.Second())) == 0 {
+		if !added {
+			added = true
+			t = t.Truncate(time.Second)
+		}
+		t = t.Add(time.Second)
+		if t.Second() == 0 {
+			goto wrap
+		}
+	}
+
+	return t.In(original)
+}
+
+// dayMatches reports whether t falls on a day the schedule fires on
+func (s *CronSchedule) dayMatches(t time.Time) bool {
+	domMatch := s.dom&(1<<uint(t.Day())) != 0
+	dowMatch := s.dow&(1<<uint(t.Weekday())) != 0
+	if s.domStar || s.dowStar {
+		return domMatch && dowMatch
+	}
+	return domMatch || dowMatch
+}
+
+// Cron sends the time to a channel every time schedule fires, like Every does for a
+// fixed interval. Time is measured with clock. It runs until ctx is cancelled, or
+// Stop is called on the returned Generator, at which point the output is closed
+func Cron(ctx context.Context, clock Clock, schedule *CronSchedule) *Generator[time.Time] {
+	ctx, cancel := context.WithCancel(ctx)
+	output := make(chan time.Time)
+	go func() {
+		defer close(output)
+		for {
+			now := clock.Now()
+			next := schedule.Next(now)
+			if next.IsZero() {
+				return // the schedule never fires again
+			}
+			timer := clock.NewTimer(next.Sub(now))
+			select {
+			case <-timer.C():
+				select {
+				case output <- next:
+				case <-ctx.Done():
+					return
+				}
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			}
+		}
+	}()
+	return &Generator[time.Time]{output, cancel}
+}
+
+// CronRunner runs registered jobs whenever their cron expressions fire
+type CronRunner struct {
+	clock Clock
+	jobs  []cronJob
+}
+
+type cronJob struct {
+	schedule *CronSchedule
+	run      func(time.Time)
+}
+
+// NewCronRunner creates a CronRunner with no jobs, measuring time with clock
+func NewCronRunner(clock Clock) *CronRunner {
+	return &CronRunner{clock: clock}
+}
+
+// Register adds a job, that will be called with the fire time whenever expr fires.
+// See ParseCron for the expression syntax. Jobs must be registered before Run is
+// called
+func (r *CronRunner) Register(expr string, job func(time.Time)) error {
+	schedule, err := ParseCron(expr)
+	if err != nil {
+		return err
+	}
+	r.jobs = append(r.jobs, cronJob{schedule, job})
+	return nil
+}
+
+// Run runs every job on its schedule, until ctx is cancelled. Each job runs on its
+// own goroutine, so a slow job doesn't hold up the others. A job never overlaps with
+// itself: if it's still running when it's next due, it runs again as soon as it
+// finishes, and any other fire times missed in the meantime are skipped. Run returns
+// once ctx is cancelled, and all running jobs have finished
+func (r *CronRunner) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	wg.Add(len(r.jobs))
+	for _, job := range r.jobs {
+		go func(job cronJob) {
+			defer wg.Done()
+			for t := range Cron(ctx, r.clock, job.schedule).Output() {
+				job.run(t)
+			}
+		}(job)
+	}
+	wg.Wait()
+}
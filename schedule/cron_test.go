@@ -0,0 +1,157 @@
+package schedule
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestParseCronErrors(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"* * * *",
+		"* * * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"5-1 * * * *",
+		"*/0 * * * *",
+		"* * * FOO *",
+		"@fortnightly",
+		"CRON_TZ=Nowhere/Special 0 9 * * *",
+	} {
+		if _, err := ParseCron(expr); err == nil {
+			t.Errorf("Expected an error parsing %q, but got none", expr)
+		}
+	}
+}
+
+func TestCronScheduleNext(t *testing.T) {
+	// A Saturday
+	from := time.Date(2026, time.October, 17, 10, 30, 15, 500, time.UTC)
+
+	tests := []struct {
+		expr     string
+		expected time.Time
+	}{
+		{"* * * * *", time.Date(2026, time.October, 17, 10, 31, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2026, time.October, 17, 10, 45, 0, 0, time.UTC)},
+		{"*/20 * * * * *", time.Date(2026, time.October, 17, 10, 30, 20, 0, time.UTC)},
+		{"0 9 * * MON-FRI", time.Date(2026, time.October, 19, 9, 0, 0, 0, time.UTC)},
+		{"0 9 * * 1-5", time.Date(2026, time.October, 19, 9, 0, 0, 0, time.UTC)},
+		{"0 12 * * sun", time.Date(2026, time.October, 18, 12, 0, 0, 0, time.UTC)},
+		{"0 12 * * 7", time.Date(2026, time.October, 18, 12, 0, 0, 0, time.UTC)},
+		{"0 12 * * SAT-SUN", time.Date(2026, time.October, 17, 12, 0, 0, 0, time.UTC)},
+		{"0 9 * * SAT-SUN", time.Date(2026, time.October, 18, 9, 0, 0, 0, time.UTC)},
+		{"0 9 * * 5-7", time.Date(2026, time.October, 18, 9, 0, 0, 0, time.UTC)},
+		{"30 8,20 * * *", time.Date(2026, time.October, 17, 20, 30, 0, 0, time.UTC)},
+		{"0 0 1 * *", time.Date(2026, time.November, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 1 * MON", time.Date(2026, time.October, 19, 0, 0, 0, 0, time.UTC)}, // day of month OR day of week
+		{"0 0 29 2 *", time.Date(2028, time.February, 29, 0, 0, 0, 0, time.UTC)},
+		{"0 0 30 2 *", time.Time{}},
+		{"@hourly", time.Date(2026, time.October, 17, 11, 0, 0, 0, time.UTC)},
+		{"@daily", time.Date(2026, time.October, 18, 0, 0, 0, 0, time.UTC)},
+		{"@weekly", time.Date(2026, time.October, 18, 0, 0, 0, 0, time.UTC)},
+		{"@monthly", time.Date(2026, time.November, 1, 0, 0, 0, 0, time.UTC)},
+		{"@yearly", time.Date(2027, time.January, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 1 JAN-MAR/2 *", time.Date(2027, time.January, 1, 0, 0, 0, 0, time.UTC)},
+		{"CRON_TZ=UTC 0 9 * * *", time.Date(2026, time.October, 18, 9, 0, 0, 0, time.UTC)},
+	}
+
+	for _, test := range tests {
+		expr := test.expr
+		if expr[0] != '@' && expr[0] != 'C' {
+			expr = "CRON_TZ=UTC " + expr
+		} else if expr[0] == '@' {
+			expr = "TZ=UTC " + expr
+		}
+		s, err := ParseCron(expr)
+		if err != nil {
+			t.Errorf("Expected no error parsing %q, but got %v", expr, err)
+			continue
+		}
+		if actual := s.Next(from); !actual.Equal(test.expected) {
+			t.Errorf("Expected %q to next fire at %v, was %v", test.expr, test.expected, actual)
+		}
+	}
+}
+
+func TestCronScheduleNextTimeZone(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("No time zone database available: %v", err)
+	}
+	s, err := ParseCron("CRON_TZ=America/New_York 0 9 * * *")
+	if err != nil {
+		t.Fatalf("Expected no error, but got %v", err)
+	}
+
+	from := time.Date(2026, time.October, 17, 12, 0, 0, 0, time.UTC) // 08:00 in New York
+	expected := time.Date(2026, time.October, 17, 9, 0, 0, 0, newYork)
+	if actual := s.Next(from); !actual.Equal(expected) {
+		t.Errorf("Expected next fire at %v, was %v", expected, actual)
+	}
+	if actual := s.Next(from); actual.Location() != time.UTC {
+		t.Errorf("Expected the result in the same location as the input, was %v", actual.Location())
+	}
+}
+
+func TestCron(t *testing.T) {
+	start := time.Date(2026, time.October, 17, 10, 30, 0, 0, time.UTC)
+	clock := NewFakeClock(start)
+	s, _ := ParseCron("CRON_TZ=UTC */15 * * * *")
+	g := Cron(context.Background(), clock, s)
+	defer g.Stop()
+
+	for _, expected := range []time.Time{
+		start.Add(15 * time.Minute),
+		start.Add(30 * time.Minute),
+		start.Add(45 * time.Minute),
+	} {
+		clock.BlockUntil(1)
+		clock.Advance(15 * time.Minute)
+		if actual := <-g.Output(); !actual.Equal(expected) {
+			t.Errorf("Expected to fire at %v, fired at %v", expected, actual)
+		}
+	}
+}
+
+func TestCronRunner(t *testing.T) {
+	start := time.Date(2026, time.October, 17, 10, 30, 0, 0, time.UTC)
+	clock := NewFakeClock(start)
+	r := NewCronRunner(clock)
+
+	ran := make(chan string)
+	if err := r.Register("CRON_TZ=UTC @hourly", func(time.Time) { ran <- "hourly" }); err != nil {
+		t.Fatalf("Expected no error, but got %v", err)
+	}
+	if err := r.Register("CRON_TZ=UTC 45 10 * * *", func(time.Time) { ran <- "daily" }); err != nil {
+		t.Fatalf("Expected no error, but got %v", err)
+	}
+	if err := r.Register("not a cron expression", func(time.Time) {}); err == nil {
+		t.Errorf("Expected an error registering an invalid expression")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan bool)
+	go func() {
+		r.Run(ctx)
+		done <- true
+	}()
+
+	clock.BlockUntil(2)
+	clock.Advance(15 * time.Minute)
+	if job := <-ran; job != "daily" {
+		t.Errorf("Expected daily job to run at 10:45, but %s ran", job)
+	}
+	clock.BlockUntil(2)
+	clock.Advance(15 * time.Minute)
+	if job := <-ran; job != "hourly" {
+		t.Errorf("Expected hourly job to run at 11:00, but %s ran", job)
+	}
+
+	cancel()
+	<-done
+}
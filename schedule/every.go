@@ -1,49 +1,56 @@
 package schedule
 
 import (
+	"context"
 	"time"
 )
 
-// Every generates a message every d duration, and sends it to a channel. Follows
+// Every calls next every d duration, and sends the result to a channel. Follows
 // the "generator pattern", where a function creates and returns a channel, and
-// sends values to it
-func Every(d time.Duration, msg string) <-chan string {
-	c := make(chan string)
-	go func() { // Launch an infinite loop producer
-		for {
-			time.Sleep(d)
-			c <- msg
-		}
-	}()
-	return c // Return channel to caller
-}
-
-// EveryStoppable generates a message every d duration, and sends it to a
-// channel. It returns a struct containing the main read-only output channel,
-// as well as a send-only channel that consumers can send to, telling every
-// to stop writing
-func EveryStoppable(d time.Duration, msg string) Channels {
-	output := make(chan string)
-	stop := make(chan bool)
+// sends values to it. Time is measured with clock
+//
+// It runs until ctx is cancelled, or Stop is called on the returned Generator,
+// at which point the output channel is closed. Ticks come from a Ticker, rather
+// than sleeping between sends, so a slow consumer doesn't push every later value
+// back. If the consumer falls behind by more than a tick, ticks are dropped
+func Every[T any](ctx context.Context, clock Clock, d time.Duration, next func() T) *Generator[T] {
+	ctx, cancel := context.WithCancel(ctx)
+	output := make(chan T)
+	ticker := clock.NewTicker(d)
 	go func() { // Launch an infinite loop producer, that also listens for stops
-		time.Sleep(d)
+		defer ticker.Stop()
+		defer close(output)
 		for {
 			select {
-			case output <- msg:
-				time.Sleep(d)
-			case <-stop:
-				close(output)
+			case <-ticker.C():
+				select {
+				case output <- next():
+				case <-ctx.Done():
+					return
+				}
+			case <-ctx.Done():
 				return
 			}
 		}
 	}()
-	return Channels{output, stop}
+	return &Generator[T]{output, cancel}
+}
+
+// Generator is a handle on a running generator, like the one started by Every:
+//  * Output is the main channel, that you can read values from
+//  * Stop stops the generator, and closes the output channel
+type Generator[T any] struct {
+	output <-chan T
+	stop   context.CancelFunc
+}
+
+// Output is the channel the generator sends values to
+func (g *Generator[T]) Output() <-chan T {
+	return g.output
 }
 
-// Channels represents the two channels returned by EveryStoppable:
-//  * output is the main channel, that you can read values from
-//  * stop will stop the output channel when a value is written to it
-type Channels struct {
-	output <-chan string
-	stop   chan<- bool
+// Stop stops the generator, which then closes its output channel. Safe to call
+// more than once
+func (g *Generator[T]) Stop() {
+	g.stop()
 }
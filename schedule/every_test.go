@@ -1,21 +1,32 @@
 package schedule
 
 import (
+	"context"
 	"testing"
 	"time"
 )
 
 func TestEvery(t *testing.T) {
+	clock := NewFakeClock(time.Now())
 	delay := 20 * time.Millisecond
 	message := "test"
 	n := 5
 	counter := 0
-	c := Every(delay, message)
-	start := time.Now()
+	g := Every(context.Background(), clock, delay, func() string { return message })
+	defer g.Stop()
 
-	// receive n messages from channel
+	// receive n messages from channel, moving the clock forward for each one
 	for i := 0; i < n; i++ {
-		actualMessage := <-c
+		// ensure the delay is respected
+		clock.Advance(delay - time.Nanosecond)
+		select {
+		case actualMessage := <-g.Output():
+			t.Errorf("Expected no message before the delay, but received %q", actualMessage)
+		default:
+		}
+
+		clock.Advance(time.Nanosecond)
+		actualMessage := <-g.Output()
 		if actualMessage != message {
 			t.Errorf("Expected value at index %d to be %q, was %q", i, message, actualMessage)
 		}
@@ -26,55 +37,60 @@ func TestEvery(t *testing.T) {
 	if counter != n {
 		t.Errorf("Expected to receive %d messages out of the channel, instead received %d", n, counter)
 	}
-
-	// ensure the delays were respected
-	runtime := time.Since(start)
-	minRuntime := time.Duration(n) * delay
-	maxRuntime := 3 * minRuntime // in a perfect world runtime is exactly minRuntime, but give a buffer
-	if runtime < minRuntime {
-		t.Errorf("Expected runtime to be at least %v, was %v", minRuntime, runtime)
-	}
-	if runtime > maxRuntime {
-		t.Errorf("Expected runtime to be at most %v, was %v", maxRuntime, runtime)
-	}
 }
 
-func TestEveryStoppable(t *testing.T) {
+func TestEveryDoesNotDrift(t *testing.T) {
+	clock := NewFakeClock(time.Now())
 	delay := 20 * time.Millisecond
-	message := "test"
-	n := 5
 	counter := 0
-	res := EveryStoppable(delay, message)
-	start := time.Now()
+	g := Every(context.Background(), clock, delay, func() int { counter++; return counter })
+	defer g.Stop()
 
-	// receive n messages from channel
-	for i := 0; i < n; i++ {
-		actualMessage := <-res.output
-		if actualMessage != message {
-			t.Errorf("Expected value at index %d to be %q, was %q", i, message, actualMessage)
-		}
-		counter++
+	// The consumer is slow, and only reads value 1 half a delay after it was due
+	clock.BlockUntil(1)
+	clock.Advance(delay)
+	clock.Advance(delay / 2)
+	if v := <-g.Output(); v != 1 {
+		t.Errorf("Expected 1, got %d", v)
 	}
 
-	// ensure we received n messages
-	if counter != n {
-		t.Errorf("Expected to receive %d messages out of the channel, instead received %d", n, counter)
+	// That doesn't push the next value back, it's still due at 2 * delay, rather than
+	// a delay after value 1 was read
+	clock.BlockUntil(1)
+	clock.Advance(delay / 2)
+	select {
+	case v := <-g.Output():
+		if v != 2 {
+			t.Errorf("Expected 2, got %d", v)
+		}
+	case <-time.After(1 * time.Second):
+		t.Errorf("Expected a value at 2 * delay")
 	}
+}
 
-	// ensure the delays were respected
-	runtime := time.Since(start)
-	minRuntime := time.Duration(n) * delay
-	maxRuntime := 3 * minRuntime // in a perfect world runtime is exactly minRuntime, but give a buffer
-	if runtime < minRuntime {
-		t.Errorf("Expected runtime to be at least %v, was %v", minRuntime, runtime)
-	}
-	if runtime > maxRuntime {
-		t.Errorf("Expected runtime to be at most %v, was %v", maxRuntime, runtime)
+func TestEveryStop(t *testing.T) {
+	clock := NewFakeClock(time.Now())
+	g := Every(context.Background(), clock, time.Second, func() string { return "test" })
+
+	// Stopping should close the output channel, even with a value waiting to be sent
+	clock.Advance(time.Second)
+	g.Stop()
+	g.Stop() // stopping twice is fine
+	for value := range g.Output() {
+		if value != "test" {
+			t.Errorf("Expected %q, got %q", "test", value)
+		}
 	}
+}
+
+func TestEveryCancel(t *testing.T) {
+	clock := NewFakeClock(time.Now())
+	ctx, cancel := context.WithCancel(context.Background())
+	g := Every(ctx, clock, time.Second, func() string { return "test" })
 
-	// Stopping should close the output channel
-	res.stop <- true
-	if value, ok := <-res.output; ok {
+	// Cancelling should close the output channel
+	cancel()
+	if value, ok := <-g.Output(); ok {
 		t.Errorf("Expected output channel to be closed, but still delivered %q", value)
 	}
 }
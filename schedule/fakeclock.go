@@ -0,0 +1,188 @@
+package schedule
+
+import (
+	"sync"
+	"time"
+)
+
+// FakeClock is a Clock for tests. Time stands still until Advance is called, at which
+// point any timers, tickers and sleeps that are due fire, in order
+type FakeClock struct {
+	mu      sync.Mutex
+	cond    *sync.Cond // signalled whenever waiters changes, for BlockUntil
+	now     time.Time
+	waiters []*waiter // timers and tickers that haven't fired (or stopped) yet
+}
+
+// NewFakeClock creates a FakeClock, starting at now
+func NewFakeClock(now time.Time) *FakeClock {
+	c := &FakeClock{now: now}
+	c.cond = sync.NewCond(&c.mu)
+	return c
+}
+
+// Now returns the fake current time
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// After returns a channel that receives the fake time, once Advance has moved the
+// clock forward by at least d
+func (c *FakeClock) After(d time.Duration) <-chan time.Time {
+	return c.NewTimer(d).C()
+}
+
+// NewTimer creates a Timer that fires once Advance has moved the clock forward by at
+// least d
+func (c *FakeClock) NewTimer(d time.Duration) Timer {
+	t := &fakeTimer{&waiter{clock: c, c: make(chan time.Time, 1)}}
+	t.Reset(d)
+	return t
+}
+
+// NewTicker creates a Ticker that fires every time Advance moves the clock past
+// another multiple of d. Like a time.Ticker, ticks are dropped if nobody receives them
+func (c *FakeClock) NewTicker(d time.Duration) Ticker {
+	if d <= 0 {
+		panic("non-positive interval for NewTicker")
+	}
+	t := &fakeTicker{&waiter{clock: c, c: make(chan time.Time, 1)}}
+	t.Reset(d)
+	return t
+}
+
+// Sleep blocks until Advance has moved the clock forward by at least d
+func (c *FakeClock) Sleep(d time.Duration) {
+	<-c.After(d)
+}
+
+// Advance moves the clock forward by d, firing everything that becomes due along the
+// way, earliest first
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	target := c.now.Add(d)
+	for {
+		var next *waiter
+		for _, w := range c.waiters {
+			if !w.at.After(target) && (next == nil || w.at.Before(next.at)) {
+				next = w
+			}
+		}
+		if next == nil {
+			break
+		}
+		c.now = next.at
+		next.fire()
+	}
+	c.now = target
+}
+
+// BlockUntil blocks until at least n timers, tickers or sleeps are waiting on the
+// clock. Tests use it to be sure a goroutine has started waiting, before they Advance
+func (c *FakeClock) BlockUntil(n int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for len(c.waiters) < n {
+		c.cond.Wait()
+	}
+}
+
+// waiter is something waiting on a FakeClock, a timer or a ticker
+type waiter struct {
+	clock  *FakeClock
+	c      chan time.Time
+	at     time.Time     // when it next fires
+	period time.Duration // 0 for a timer, which fires once
+}
+
+func (w *waiter) C() <-chan time.Time {
+	return w.c
+}
+
+// fire sends the current time, then either schedules the next tick, or stops waiting.
+// Must be called with the clock locked
+func (w *waiter) fire() {
+	select {
+	case w.c <- w.clock.now:
+	default: // nobody took the last value, drop this one, like time.Ticker does
+	}
+	if w.period > 0 {
+		w.at = w.at.Add(w.period)
+	} else {
+		w.clock.remove(w)
+	}
+}
+
+// start waits to fire at d from now, or fires right away if that's already passed.
+// Must be called with the clock locked
+func (w *waiter) start(d time.Duration) {
+	w.at = w.clock.now.Add(d)
+	if d <= 0 {
+		w.fire()
+		return
+	}
+	w.clock.waiters = append(w.clock.waiters, w)
+	w.clock.cond.Broadcast()
+}
+
+// fakeTimer implements the Timer interface for a FakeClock
+type fakeTimer struct {
+	*waiter
+}
+
+// Stop stops the timer, returning true if it hadn't fired yet
+func (t *fakeTimer) Stop() bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+	return t.clock.remove(t.waiter)
+}
+
+// Reset makes the timer fire once d has passed from now, returning true if it was
+// still waiting to fire
+func (t *fakeTimer) Reset(d time.Duration) bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+	active := t.clock.remove(t.waiter)
+	t.start(d)
+	return active
+}
+
+// fakeTicker implements the Ticker interface for a FakeClock
+type fakeTicker struct {
+	*waiter
+}
+
+// Stop stops the ticker
+func (t *fakeTicker) Stop() {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+	t.clock.remove(t.waiter)
+}
+
+// Reset makes the ticker fire every d, starting from now
+func (t *fakeTicker) Reset(d time.Duration) {
+	if d <= 0 {
+		panic("non-positive interval for Ticker.Reset")
+	}
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+	t.clock.remove(t.waiter)
+	t.period = d
+	t.start(d)
+}
+
+// remove stops the clock waiting on w, returning true if it was waiting. Must be
+// called with the clock locked
+func (c *FakeClock) remove(w *waiter) bool {
+	for i, other := range c.waiters {
+		if other == w {
+			c.waiters = append(c.waiters[:i], c.waiters[i+1:]...)
+			c.cond.Broadcast()
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,89 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFakeClockTimer(t *testing.T) {
+	start := time.Now()
+	clock := NewFakeClock(start)
+	timer := clock.NewTimer(10 * time.Second)
+
+	clock.Advance(9 * time.Second)
+	select {
+	case <-timer.C():
+		t.Errorf("Expected timer not to fire before it's due")
+	default:
+	}
+
+	clock.Advance(5 * time.Second)
+	if fired := <-timer.C(); !fired.Equal(start.Add(10 * time.Second)) {
+		t.Errorf("Expected timer to fire at %v, fired at %v", start.Add(10*time.Second), fired)
+	}
+	if now := clock.Now(); !now.Equal(start.Add(14 * time.Second)) {
+		t.Errorf("Expected clock to be at %v, was %v", start.Add(14*time.Second), now)
+	}
+
+	// Once fired, Stop reports the timer wasn't active, and Reset makes it fire again
+	if timer.Stop() {
+		t.Errorf("Expected Stop to return false for a timer that already fired")
+	}
+	timer.Reset(time.Second)
+	if !timer.Stop() {
+		t.Errorf("Expected Stop to return true for a timer that hadn't fired")
+	}
+	clock.Advance(time.Second)
+	select {
+	case <-timer.C():
+		t.Errorf("Expected stopped timer not to fire")
+	default:
+	}
+}
+
+func TestFakeClockTicker(t *testing.T) {
+	start := time.Now()
+	clock := NewFakeClock(start)
+	ticker := clock.NewTicker(time.Second)
+
+	clock.Advance(time.Second)
+	if tick := <-ticker.C(); !tick.Equal(start.Add(time.Second)) {
+		t.Errorf("Expected tick at %v, got %v", start.Add(time.Second), tick)
+	}
+
+	// Ticks nobody receives are dropped, rather than piling up
+	clock.Advance(5 * time.Second)
+	if tick := <-ticker.C(); !tick.Equal(start.Add(2 * time.Second)) {
+		t.Errorf("Expected tick at %v, got %v", start.Add(2*time.Second), tick)
+	}
+	select {
+	case tick := <-ticker.C():
+		t.Errorf("Expected only one tick to be buffered, but got another at %v", tick)
+	default:
+	}
+
+	ticker.Stop()
+	clock.Advance(5 * time.Second)
+	select {
+	case <-ticker.C():
+		t.Errorf("Expected stopped ticker not to tick")
+	default:
+	}
+}
+
+func TestFakeClockSleep(t *testing.T) {
+	clock := NewFakeClock(time.Now())
+	done := make(chan bool)
+	go func() {
+		clock.Sleep(time.Minute)
+		done <- true
+	}()
+
+	clock.BlockUntil(1)
+	clock.Advance(time.Minute)
+	select {
+	case <-done:
+	case <-time.After(1 * time.Second):
+		t.Errorf("Expected Sleep to return once the clock advanced")
+	}
+}
@@ -0,0 +1,178 @@
+package schedule
+
+import (
+	"context"
+	"time"
+)
+
+// Debounce only sends a value once the input has been quiet for the given duration,
+// so a burst of values becomes a single value, the last one in the burst. Time is
+// measured with clock
+//
+// When the input is closed, any value still waiting out the quiet period is sent
+// straight away, then the output is closed. If ctx is cancelled, the output is
+// closed, and any waiting value is dropped
+func Debounce[T any](ctx context.Context, clock Clock, c <-chan T, quiet time.Duration) <-chan T {
+	out := make(chan T)
+	go func() {
+		defer close(out)
+		var latest T
+		var timer Timer
+		var fire <-chan time.Time // nil, and so never fires, unless a value is waiting
+		defer func() {
+			if timer != nil {
+				timer.Stop()
+			}
+		}()
+
+		for {
+			select {
+			case v, ok := <-c:
+				if !ok {
+					if fire != nil {
+						send(ctx, out, latest)
+					}
+					return
+				}
+				// Every value restarts the quiet period
+				latest = v
+				if timer != nil {
+					timer.Stop()
+				}
+				timer = clock.NewTimer(quiet)
+				fire = timer.C()
+			case <-fire:
+				timer, fire = nil, nil
+				if !send(ctx, out, latest) {
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out
+}
+
+// ThrottleOptions configures Throttle. If neither option is set, Leading is used
+type ThrottleOptions struct {
+	Leading  bool // send the first value in an interval as soon as it arrives
+	Trailing bool // send the last value in an interval once the interval ends
+}
+
+// Throttle sends at most one value per interval. With the Leading option, the first
+// value is sent straight away, and others are dropped until the interval is over.
+// With the Trailing option, the last value to arrive during an interval is sent
+// at the end of it. With both, a burst sends its first and last values. Time is
+// measured with clock
+//
+// When the input is closed, any trailing value that's waiting is sent straight away,
+// then the output is closed. If ctx is cancelled, the output is closed, and any
+// waiting value is dropped
+func Throttle[T any](ctx context.Context, clock Clock, c <-chan T, interval time.Duration, options ThrottleOptions) <-chan T {
+	if !options.Leading && !options.Trailing {
+		options.Leading = true
+	}
+
+	out := make(chan T)
+	go func() {
+		defer close(out)
+		var pending T
+		hasPending := false
+		var timer Timer
+		var intervalOver <-chan time.Time // nil, and so never fires, unless an interval is running
+		defer func() {
+			if timer != nil {
+				timer.Stop()
+			}
+		}()
+		startInterval := func() {
+			timer = clock.NewTimer(interval)
+			intervalOver = timer.C()
+		}
+
+		for {
+			select {
+			case v, ok := <-c:
+				if !ok {
+					if hasPending {
+						send(ctx, out, pending)
+					}
+					return
+				}
+				switch {
+				case intervalOver == nil && options.Leading:
+					startInterval()
+					if !send(ctx, out, v) {
+						return
+					}
+				case intervalOver == nil:
+					startInterval()
+					pending, hasPending = v, true
+				case options.Trailing:
+					pending, hasPending = v, true
+				}
+			case <-intervalOver:
+				timer, intervalOver = nil, nil
+				if hasPending {
+					// Sending a trailing value starts a new interval, so values stay spaced out
+					var zero T
+					v := pending
+					pending, hasPending = zero, false
+					startInterval()
+					if !send(ctx, out, v) {
+						return
+					}
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out
+}
+
+// Sample sends the latest value from the input once every interval. If no new value
+// has arrived since the last tick, nothing is sent for that tick. Time is measured
+// with clock. The output is closed when the input is closed, or ctx is cancelled,
+// and any value that hasn't been sampled yet is dropped
+func Sample[T any](ctx context.Context, clock Clock, c <-chan T, interval time.Duration) <-chan T {
+	out := make(chan T)
+	ticker := clock.NewTicker(interval)
+	go func() {
+		defer ticker.Stop()
+		defer close(out)
+		var latest T
+		hasLatest := false
+
+		for {
+			select {
+			case v, ok := <-c:
+				if !ok {
+					return
+				}
+				latest, hasLatest = v, true
+			case <-ticker.C():
+				if hasLatest {
+					hasLatest = false
+					if !send(ctx, out, latest) {
+						return
+					}
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out
+}
+
+// send sends v on out, returning false if ctx was cancelled first
+func send[T any](ctx context.Context, out chan<- T, v T) bool {
+	select {
+	case out <- v:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
@@ -0,0 +1,173 @@
+package schedule
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// timerClock is a FakeClock that tells the test whenever a timer is created, so the
+// test knows when it's safe to Advance
+type timerClock struct {
+	*FakeClock
+	created chan struct{}
+}
+
+func newTimerClock() *timerClock {
+	return &timerClock{NewFakeClock(time.Now()), make(chan struct{}, 100)}
+}
+
+func (c *timerClock) NewTimer(d time.Duration) Timer {
+	t := c.FakeClock.NewTimer(d)
+	c.created <- struct{}{}
+	return t
+}
+
+// receiveNow returns whatever is ready on c right now, without waiting
+func receiveNow[T any](c <-chan T) []T {
+	var received []T
+	for {
+		select {
+		case v := <-c:
+			received = append(received, v)
+		default:
+			return received
+		}
+	}
+}
+
+func TestDebounce(t *testing.T) {
+	clock := newTimerClock()
+	quiet := 100 * time.Millisecond
+	input := make(chan int)
+	c := Debounce(context.Background(), clock, input, quiet)
+
+	// A burst of values, each arriving before the quiet period is over
+	for i := 1; i <= 3; i++ {
+		input <- i
+		<-clock.created
+		clock.Advance(quiet - time.Millisecond)
+	}
+	if received := receiveNow(c); len(received) != 0 {
+		t.Errorf("Expected nothing to be sent during the burst, got %v", received)
+	}
+
+	// Once it's quiet, only the last value is sent
+	clock.Advance(time.Millisecond)
+	if v := <-c; v != 3 {
+		t.Errorf("Expected 3, got %d", v)
+	}
+
+	// A value waiting when the input closes is sent straight away
+	input <- 4
+	<-clock.created
+	close(input)
+	if v := <-c; v != 4 {
+		t.Errorf("Expected 4, got %d", v)
+	}
+	if v, ok := <-c; ok {
+		t.Errorf("Expected output channel to be closed, but delivered %d", v)
+	}
+}
+
+func TestThrottle(t *testing.T) {
+	interval := 100 * time.Millisecond
+	tests := []struct {
+		name     string
+		options  ThrottleOptions
+		trailing []int // what's sent at the end of the interval
+	}{
+		{"Leading", ThrottleOptions{Leading: true}, nil},
+		{"Default", ThrottleOptions{}, nil},
+		{"Trailing", ThrottleOptions{Trailing: true}, []int{3}},
+		{"Both", ThrottleOptions{Leading: true, Trailing: true}, []int{3}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			clock := newTimerClock()
+			input := make(chan int)
+			c := Throttle(context.Background(), clock, input, interval, test.options)
+
+			// A burst of values, all within one interval
+			input <- 1
+			if test.options.Leading || !test.options.Trailing {
+				if v := <-c; v != 1 {
+					t.Errorf("Expected leading value 1, got %d", v)
+				}
+			}
+			<-clock.created
+			input <- 2
+			input <- 3
+			input <- 3 // a repeat, so we know the goroutine has finished with 3 before we look
+			if received := receiveNow(c); len(received) != 0 {
+				t.Errorf("Expected nothing else during the interval, got %v", received)
+			}
+
+			clock.Advance(interval)
+			var received []int
+			if test.trailing != nil {
+				received = append(received, <-c)
+			}
+			if !reflect.DeepEqual(received, test.trailing) {
+				t.Errorf("Expected %v at the end of the interval, got %v", test.trailing, received)
+			}
+		})
+	}
+}
+
+func TestThrottleSpacesOutTrailingValues(t *testing.T) {
+	clock := newTimerClock()
+	interval := 100 * time.Millisecond
+	input := make(chan int)
+	c := Throttle(context.Background(), clock, input, interval, ThrottleOptions{Trailing: true})
+
+	input <- 1
+	<-clock.created
+	clock.Advance(interval)
+	if v := <-c; v != 1 {
+		t.Errorf("Expected 1, got %d", v)
+	}
+
+	// Sending 1 started another interval, so 2 has to wait for that one to end
+	<-clock.created
+	input <- 2
+	clock.Advance(interval - time.Millisecond)
+	if received := receiveNow(c); len(received) != 0 {
+		t.Errorf("Expected nothing before the interval ends, got %v", received)
+	}
+	clock.Advance(time.Millisecond)
+	if v := <-c; v != 2 {
+		t.Errorf("Expected 2, got %d", v)
+	}
+}
+
+func TestSample(t *testing.T) {
+	clock := NewFakeClock(time.Now())
+	interval := 100 * time.Millisecond
+	input := make(chan int)
+	c := Sample(context.Background(), clock, input, interval)
+
+	input <- 1
+	input <- 2
+	input <- 2 // a repeat, so we know the goroutine has finished with the first 2
+	clock.Advance(interval)
+	if v := <-c; v != 2 {
+		t.Errorf("Expected the latest value 2, got %d", v)
+	}
+
+	// Ticks without a new value send nothing, so the next thing out is the next value
+	clock.Advance(interval)
+	clock.Advance(interval)
+	input <- 3
+	clock.Advance(interval)
+	if v := <-c; v != 3 {
+		t.Errorf("Expected 3, got %d", v)
+	}
+
+	close(input)
+	if v, ok := <-c; ok {
+		t.Errorf("Expected output channel to be closed, but delivered %d", v)
+	}
+}
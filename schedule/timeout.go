@@ -1,38 +1,81 @@
 package schedule
 
 import (
+	"context"
 	"errors"
 	"time"
 )
 
-// ReceiveWithTimeout receives a single value from a channel, with a timeout.
-// It returns either the value, or a timeout error
-func ReceiveWithTimeout(c <-chan string, timeout time.Duration) (string, error) {
-	for {
-		select {
-		case s := <-c:
-			return s, nil
-		case <-time.After(timeout):
-			return "", errors.New("Timeout")
+var (
+	// ErrTimeout is returned when a timeout expires before a value can be sent or received
+	ErrTimeout = errors.New("timeout")
+	// ErrClosed is returned when receiving from a channel that has been closed
+	ErrClosed = errors.New("channel closed")
+	// ErrNotReady is returned by TryReceive when there's no value waiting
+	ErrNotReady = errors.New("no value ready")
+)
+
+// Receive receives a single value from a channel, with a timeout. It returns either
+// the value, or an error:
+//  * ErrTimeout if no value arrives within timeout
+//  * ErrClosed if the channel is closed
+//  * ctx.Err() if ctx is done first
+//
+// If timeout is 0 or less, it waits until ctx is done. Time is measured with clock
+func Receive[T any](ctx context.Context, clock Clock, c <-chan T, timeout time.Duration) (T, error) {
+	var zero T
+	var timedOut <-chan time.Time
+	if timeout > 0 {
+		timer := clock.NewTimer(timeout)
+		defer timer.Stop()
+		timedOut = timer.C()
+	}
+
+	select {
+	case v, ok := <-c:
+		if !ok {
+			return zero, ErrClosed
 		}
+		return v, nil
+	case <-timedOut:
+		return zero, ErrTimeout
+	case <-ctx.Done():
+		return zero, ctx.Err()
 	}
 }
 
-// ReceiveMultiWithTimeout receives 0-to-many values from a channel, and sends them
-// to an output channel. Once a timeout is reached, it closes the output channel
-func ReceiveMultiWithTimeout(c <-chan string, timeout time.Duration) <-chan string {
-	out := make(chan string)
-	timeoutChan := time.After(timeout)
-	go func() {
-		for {
-			select {
-			case s := <-c:
-				out <- s
-			case <-timeoutChan:
-				close(out)
-				return
-			}
+// TryReceive receives a value from a channel, if one is ready right now, without
+// blocking. Otherwise it returns ErrNotReady, or ErrClosed if the channel is closed
+func TryReceive[T any](c <-chan T) (T, error) {
+	var zero T
+	select {
+	case v, ok := <-c:
+		if !ok {
+			return zero, ErrClosed
 		}
-	}()
-	return out
+		return v, nil
+	default:
+		return zero, ErrNotReady
+	}
+}
+
+// SendWithTimeout sends a value to a channel, with a timeout. It returns ErrTimeout if
+// nobody receives the value within timeout, or ctx.Err() if ctx is done first. If
+// timeout is 0 or less, it waits until ctx is done. Time is measured with clock
+func SendWithTimeout[T any](ctx context.Context, clock Clock, c chan<- T, v T, timeout time.Duration) error {
+	var timedOut <-chan time.Time
+	if timeout > 0 {
+		timer := clock.NewTimer(timeout)
+		defer timer.Stop()
+		timedOut = timer.C()
+	}
+
+	select {
+	case c <- v:
+		return nil
+	case <-timedOut:
+		return ErrTimeout
+	case <-ctx.Done():
+		return ctx.Err()
+	}
 }
@@ -1,57 +1,96 @@
 package schedule
 
 import (
+	"context"
+	"errors"
 	"testing"
 	"time"
 )
 
-func TestReceiveWithTimeoutSuccess(t *testing.T) {
-	c := make(chan string)
-	expected := "test"
+func TestReceiveSuccess(t *testing.T) {
+	clock := NewFakeClock(time.Now())
+	c := make(chan int)
+	expected := 42
 	go func() {
-		time.Sleep(10 * time.Millisecond)
 		c <- expected
 	}()
 
-	actual, err := ReceiveWithTimeout(c, 1*time.Second)
+	actual, err := Receive(context.Background(), clock, c, 1*time.Second)
 	if err != nil {
 		t.Errorf("Expected no error, but got %v", err)
 	} else if actual != expected {
-		t.Errorf("Expected %q, but got %q", expected, actual)
+		t.Errorf("Expected %d, but got %d", expected, actual)
 	}
 }
 
-func TestReceiveWithTimeoutFailure(t *testing.T) {
-	c := make(chan string)
+func TestReceiveTimeout(t *testing.T) {
+	clock := NewFakeClock(time.Now())
+	c := make(chan string) // nothing is ever sent
+	errs := make(chan error)
 	go func() {
-		time.Sleep(1 * time.Second)
-		c <- "test"
+		_, err := Receive(context.Background(), clock, c, 10*time.Millisecond)
+		errs <- err
 	}()
 
-	_, err := ReceiveWithTimeout(c, 10*time.Millisecond)
-	if err == nil || err.Error() != "Timeout" {
-		t.Errorf("Unexpected error %v", err)
+	clock.BlockUntil(1)
+	clock.Advance(10 * time.Millisecond)
+	if err := <-errs; !errors.Is(err, ErrTimeout) {
+		t.Errorf("Expected %v, but got %v", ErrTimeout, err)
+	}
+}
+
+func TestReceiveClosed(t *testing.T) {
+	c := make(chan string)
+	close(c)
+	if _, err := Receive(context.Background(), NewFakeClock(time.Now()), c, time.Second); !errors.Is(err, ErrClosed) {
+		t.Errorf("Expected %v, but got %v", ErrClosed, err)
+	}
+}
+
+func TestReceiveCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := Receive(ctx, NewFakeClock(time.Now()), make(chan string), 0)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected %v, but got %v", context.Canceled, err)
+	}
+}
+
+func TestTryReceive(t *testing.T) {
+	c := make(chan string, 1)
+	if _, err := TryReceive(c); !errors.Is(err, ErrNotReady) {
+		t.Errorf("Expected %v from an empty channel, but got %v", ErrNotReady, err)
+	}
+
+	c <- "test"
+	if v, err := TryReceive(c); err != nil || v != "test" {
+		t.Errorf("Expected %q, but got %q and error %v", "test", v, err)
+	}
+
+	close(c)
+	if _, err := TryReceive(c); !errors.Is(err, ErrClosed) {
+		t.Errorf("Expected %v from a closed channel, but got %v", ErrClosed, err)
 	}
 }
 
-func TestReceiveMultiWithTimeout(t *testing.T) {
-	frequency := 5 * time.Millisecond
-	message := "test"
-	timeout := 100 * time.Millisecond
-	c := ReceiveMultiWithTimeout(Every(frequency, message), timeout)
-	counter := 0
-
-	for s := range c {
-		counter++
-		if s != message {
-			t.Errorf("Expected %q, got %q", message, s)
-		}
-	}
-
-	maxCount := int(timeout/frequency) + 1 // 1 is an arbitrary buffer, time is imprecise
-	if counter == 0 {
-		t.Errorf("Expected to receive values from channel, but didn't")
-	} else if counter > maxCount {
-		t.Errorf("Expected count to be no higher than %d, was %d", maxCount, counter)
+func TestSendWithTimeout(t *testing.T) {
+	clock := NewFakeClock(time.Now())
+	c := make(chan string)
+	go func() {
+		<-c
+	}()
+	if err := SendWithTimeout(context.Background(), clock, c, "test", time.Second); err != nil {
+		t.Errorf("Expected no error, but got %v", err)
+	}
+
+	// Nobody's receiving any more
+	errs := make(chan error)
+	go func() {
+		errs <- SendWithTimeout(context.Background(), clock, c, "test", time.Second)
+	}()
+	clock.BlockUntil(1)
+	clock.Advance(time.Second)
+	if err := <-errs; !errors.Is(err, ErrTimeout) {
+		t.Errorf("Expected %v, but got %v", ErrTimeout, err)
 	}
 }
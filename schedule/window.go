@@ -0,0 +1,159 @@
+package schedule
+
+import (
+	"context"
+	"time"
+)
+
+// WindowLimits are the ways a window can close. Zero values mean no limit
+type WindowLimits struct {
+	Deadline    time.Duration // close this long after the window opens
+	IdleTimeout time.Duration // close if no value arrives for this long
+	MaxCount    int           // close after this many values
+}
+
+// WindowReason is why a window closed
+type WindowReason int
+
+const (
+	// WindowDeadline means WindowLimits.Deadline was reached
+	WindowDeadline WindowReason = iota
+	// WindowIdle means no value arrived within WindowLimits.IdleTimeout
+	WindowIdle
+	// WindowMaxCount means WindowLimits.MaxCount values were received
+	WindowMaxCount
+	// WindowInputClosed means the input channel was closed
+	WindowInputClosed
+	// WindowCancelled means the context was cancelled
+	WindowCancelled
+)
+
+func (r WindowReason) String() string {
+	switch r {
+	case WindowDeadline:
+		return "deadline"
+	case WindowIdle:
+		return "idle"
+	case WindowMaxCount:
+		return "max count"
+	case WindowInputClosed:
+		return "input closed"
+	case WindowCancelled:
+		return "cancelled"
+	}
+	return "unknown"
+}
+
+// Window is a handle on a window opened by ReceiveWindow
+type Window[T any] struct {
+	output <-chan T
+	done   chan struct{}
+	reason WindowReason
+}
+
+// Output is the channel values in the window are sent to. It's closed when the
+// window closes
+func (w *Window[T]) Output() <-chan T {
+	return w.output
+}
+
+// Reason blocks until the window closes, then returns why it closed
+func (w *Window[T]) Reason() WindowReason {
+	<-w.done
+	return w.reason
+}
+
+// ReceiveWindow receives 0-to-many values from a channel, and sends them to an output
+// channel, until one of limits is hit, the input is closed, or ctx is cancelled,
+// whichever comes first. Then it closes the output channel. Time is measured with
+// clock
+//
+// The idle timeout resets each time a value is sent on, so it closes the window once a
+// stream goes quiet. It's paused while waiting for you to receive a value, since a
+// slow reader doesn't mean the stream is quiet. Once a value has been taken from c,
+// it's always sent on, even if the deadline passes while waiting for you to receive
+// it, so consecutive windows over one stream don't lose values. It's only dropped if
+// ctx is cancelled. If you stop reading from the output before it's closed, cancel
+// ctx, so the window isn't left waiting to send forever
+func ReceiveWindow[T any](ctx context.Context, clock Clock, c <-chan T, limits WindowLimits) *Window[T] {
+	out := make(chan T)
+	w := &Window[T]{output: out, done: make(chan struct{})}
+
+	// nil channels block forever, so limits we don't have never fire
+	var deadline, idle <-chan time.Time
+	var deadlineTimer, idleTimer Timer
+	if limits.Deadline > 0 {
+		deadlineTimer = clock.NewTimer(limits.Deadline)
+		deadline = deadlineTimer.C()
+	}
+	if limits.IdleTimeout > 0 {
+		idleTimer = clock.NewTimer(limits.IdleTimeout)
+		idle = idleTimer.C()
+	}
+
+	go func() {
+		defer close(w.done)
+		defer close(out)
+		defer func() {
+			for _, timer := range []Timer{deadlineTimer, idleTimer} {
+				if timer != nil {
+					timer.Stop()
+				}
+			}
+		}()
+
+		count := 0
+		for {
+			// If the deadline has passed, don't take any more values, even if some are waiting
+			select {
+			case <-deadline:
+				w.reason = WindowDeadline
+				return
+			default:
+			}
+
+			select {
+			case v, ok := <-c:
+				if !ok {
+					w.reason = WindowInputClosed
+					return
+				}
+				if idleTimer != nil {
+					// Pause the idle timeout while we wait for someone to receive
+					if !idleTimer.Stop() {
+						// It may have fired just now, drain it before it's reset
+						select {
+						case <-idle:
+						default:
+						}
+					}
+				}
+				// We've taken v from c, so send it on, even if the deadline passes meanwhile
+				select {
+				case out <- v:
+				case <-ctx.Done():
+					w.reason = WindowCancelled
+					return
+				}
+				if idleTimer != nil {
+					idleTimer.Reset(limits.IdleTimeout)
+				}
+				count++
+				if limits.MaxCount > 0 && count >= limits.MaxCount {
+					w.reason = WindowMaxCount
+					return
+				}
+			case <-deadline:
+				w.reason = WindowDeadline
+				return
+			case <-idle:
+				w.reason = WindowIdle
+				return
+			case <-ctx.Done():
+				w.reason = WindowCancelled
+				return
+			}
+		}
+	}()
+	return w
+}
@@ -0,0 +1,149 @@
+package schedule
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestReceiveWindowDeadline(t *testing.T) {
+	clock := NewFakeClock(time.Now())
+	message := "test"
+	timeout := 100 * time.Millisecond
+	input := make(chan string)
+	w := ReceiveWindow(context.Background(), clock, input, WindowLimits{Deadline: timeout})
+
+	// everything sent before the deadline is received
+	n := 3
+	for i := 0; i < n; i++ {
+		input <- message
+		if s := <-w.Output(); s != message {
+			t.Errorf("Expected %q, got %q", message, s)
+		}
+	}
+
+	// after the deadline, the output channel is closed
+	clock.Advance(timeout)
+	if s, ok := <-w.Output(); ok {
+		t.Errorf("Expected output channel to be closed, but delivered %q", s)
+	}
+	if reason := w.Reason(); reason != WindowDeadline {
+		t.Errorf("Expected window to close because of %v, but was %v", WindowDeadline, reason)
+	}
+}
+
+func TestReceiveWindowIdle(t *testing.T) {
+	clock := NewFakeClock(time.Now())
+	idle := 10 * time.Millisecond
+	input := make(chan int)
+	w := ReceiveWindow(context.Background(), clock, input, WindowLimits{Deadline: time.Second, IdleTimeout: idle})
+
+	// Each value resets the idle timeout, so this goes on well past a single timeout
+	for i := 0; i < 5; i++ {
+		clock.Advance(idle - time.Millisecond)
+		input <- i
+		if v := <-w.Output(); v != i {
+			t.Errorf("Expected %d, got %d", i, v)
+		}
+	}
+
+	// Once the stream goes quiet, the window closes
+	clock.BlockUntil(2)
+	clock.Advance(idle)
+	for range w.Output() {
+	}
+	if reason := w.Reason(); reason != WindowIdle {
+		t.Errorf("Expected window to close because of %v, but was %v", WindowIdle, reason)
+	}
+}
+
+func TestReceiveWindowDeadlineWhileSending(t *testing.T) {
+	clock := NewFakeClock(time.Now())
+	timeout := 100 * time.Millisecond
+	input := make(chan int)
+	w := ReceiveWindow(context.Background(), clock, input, WindowLimits{Deadline: timeout})
+
+	// The window has taken 42 from the input, but nobody's received it yet
+	input <- 42
+	clock.Advance(timeout)
+	if v, ok := <-w.Output(); !ok || v != 42 {
+		t.Errorf("Expected 42 to be delivered, even though the deadline passed, got %d", v)
+	}
+	if v, ok := <-w.Output(); ok {
+		t.Errorf("Expected output channel to be closed, but delivered %d", v)
+	}
+	if reason := w.Reason(); reason != WindowDeadline {
+		t.Errorf("Expected window to close because of %v, but was %v", WindowDeadline, reason)
+	}
+}
+
+func TestReceiveWindowIdleWhileSending(t *testing.T) {
+	clock := NewFakeClock(time.Now())
+	idle := 10 * time.Millisecond
+	input := make(chan int)
+	w := ReceiveWindow(context.Background(), clock, input, WindowLimits{IdleTimeout: idle})
+
+	// A slow reader doesn't make the stream idle
+	input <- 1
+	clock.Advance(2 * idle)
+	if v := <-w.Output(); v != 1 {
+		t.Errorf("Expected 1, got %d", v)
+	}
+	input <- 2
+	if v, ok := <-w.Output(); !ok || v != 2 {
+		t.Errorf("Expected 2, the window shouldn't have gone idle, got %d", v)
+	}
+
+	clock.BlockUntil(1)
+	clock.Advance(idle)
+	for range w.Output() {
+	}
+	if reason := w.Reason(); reason != WindowIdle {
+		t.Errorf("Expected window to close because of %v, but was %v", WindowIdle, reason)
+	}
+}
+
+func TestReceiveWindowMaxCount(t *testing.T) {
+	input := make(chan int, 10)
+	for i := 0; i < 10; i++ {
+		input <- i
+	}
+	w := ReceiveWindow(context.Background(), NewFakeClock(time.Now()), input, WindowLimits{MaxCount: 3})
+
+	counter := 0
+	for range w.Output() {
+		counter++
+	}
+	if counter != 3 {
+		t.Errorf("Expected to receive 3 values, received %d", counter)
+	}
+	if reason := w.Reason(); reason != WindowMaxCount {
+		t.Errorf("Expected window to close because of %v, but was %v", WindowMaxCount, reason)
+	}
+}
+
+func TestReceiveWindowInputClosed(t *testing.T) {
+	input := make(chan int)
+	close(input)
+	w := ReceiveWindow(context.Background(), NewFakeClock(time.Now()), input, WindowLimits{})
+
+	if v, ok := <-w.Output(); ok {
+		t.Errorf("Expected output channel to be closed, but delivered %d", v)
+	}
+	if reason := w.Reason(); reason != WindowInputClosed {
+		t.Errorf("Expected window to close because of %v, but was %v", WindowInputClosed, reason)
+	}
+}
+
+func TestReceiveWindowCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	input := make(chan int, 1)
+	input <- 1
+	w := ReceiveWindow(ctx, NewFakeClock(time.Now()), input, WindowLimits{})
+
+	// Nobody reads the output, but cancelling still closes the window
+	cancel()
+	if reason := w.Reason(); reason != WindowCancelled {
+		t.Errorf("Expected window to close because of %v, but was %v", WindowCancelled, reason)
+	}
+}
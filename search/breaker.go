@@ -0,0 +1,22 @@
+package search
+
+import (
+	"context"
+
+	"github.com/yashap/concurrency/breaker"
+)
+
+// WithBreaker wraps a Search with a circuit breaker, so once it keeps failing, searches
+// fail fast with breaker.ErrOpen, rather than waiting on it. Give each replica its own
+// breaker, so Replicas or Hedged skip straight past a bad one to the others
+func WithBreaker(search Search, b *breaker.Breaker) Search {
+	return func(ctx context.Context, query string) ([]Result, error) {
+		done, err := b.Allow()
+		if err != nil {
+			return nil, err
+		}
+		results, err := search(ctx, query)
+		done(err)
+		return results, err
+	}
+}
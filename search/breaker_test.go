@@ -0,0 +1,34 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/yashap/concurrency/breaker"
+	"github.com/yashap/concurrency/schedule"
+)
+
+func TestWithBreaker(t *testing.T) {
+	ctx := context.Background()
+	b := breaker.New(schedule.NewFakeClock(time.Now()), breaker.Options{ConsecutiveFailures: 2, CoolDown: time.Minute})
+	var calls atomic.Int64
+	broken := WithBreaker(counted(fixed("", errors.New("boom")), &calls), b)
+
+	for i := 0; i < 2; i++ {
+		broken(ctx, "golang")
+	}
+	if _, err := broken(ctx, "golang"); !errors.Is(err, breaker.ErrOpen) {
+		t.Errorf("Expected %v, but got %v", breaker.ErrOpen, err)
+	}
+
+	// The working replica still answers, without the broken one being called again
+	if results, err := First(ctx, "golang", broken, fixed("ok", nil)); err != nil || title(results) != "ok" {
+		t.Errorf("Expected %q from the working replica, but got %v and error %v", "ok", results, err)
+	}
+	if calls.Load() != 2 {
+		t.Errorf("Expected the broken replica to be called 2 times, was %d", calls.Load())
+	}
+}
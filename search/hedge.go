@@ -0,0 +1,166 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"math"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/yashap/concurrency/schedule"
+)
+
+// HedgeOptions configures Hedged
+type HedgeOptions struct {
+	Delay       time.Duration // how long to wait for a replica before also trying the next one
+	Percentile  float64       // if set, e.g. 0.95, wait this percentile of recent latencies instead, once there are enough
+	Window      int           // how many recent latencies to keep, for Percentile, 100 if not set
+	MaxAttempts int           // how many replicas to try at most, all of them if not set
+}
+
+// Hedged combines a set of Search services into one, like Replicas, but rather than
+// searching them all at once, it searches one, and only tries the next if that's
+// slow, i.e. takes longer than the hedge delay, or fails. The first successful result
+// wins, and the others are cancelled. Most searches only hit one replica, but the
+// slow ones, which Replicas helps with, still get a second chance. Time is measured
+// with clock
+//
+// Latencies are tracked across calls, so keep hold of the Search it returns, rather
+// than making a new one per query. The replicas that lose are the slow ones, so we
+// count how long they'd been running when cancelled too. It's less than they'd really
+// have taken, but leaving them out would pull the percentile towards the fast replicas
+func Hedged(clock schedule.Clock, options HedgeOptions, replicas ...Search) Search {
+	if options.Window <= 0 {
+		options.Window = 100
+	}
+	if options.MaxAttempts <= 0 || options.MaxAttempts > len(replicas) {
+		options.MaxAttempts = len(replicas)
+	}
+	h := &hedge{clock: clock, options: options, replicas: replicas}
+	return h.search
+}
+
+type hedge struct {
+	clock    schedule.Clock
+	options  HedgeOptions
+	replicas []Search
+
+	mu        sync.Mutex
+	latencies []time.Duration // a ring buffer of the most recent latencies
+	next      int             // where the next latency goes in latencies
+}
+
+// record adds the latency of a search that succeeded, or lost to one that did
+func (h *hedge) record(d time.Duration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if len(h.latencies) < h.options.Window {
+		h.latencies = append(h.latencies, d)
+		return
+	}
+	h.latencies[h.next] = d
+	h.next = (h.next + 1) % h.options.Window
+}
+
+// delay is how long to wait before trying the next replica. Until there are enough
+// latencies for the percentile to mean anything, e.g. 20 for the p95, it's the fixed
+// delay, otherwise a single fast search would have us hedging almost every time
+func (h *hedge) delay() time.Duration {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.options.Percentile <= 0 || len(h.latencies) < h.samples() {
+		return h.options.Delay
+	}
+	sorted := slices.Clone(h.latencies)
+	slices.Sort(sorted)
+	i := int(math.Ceil(h.options.Percentile*float64(len(sorted)))) - 1
+	return sorted[min(max(i, 0), len(sorted)-1)]
+}
+
+// samples is how many latencies the percentile needs, at most a full window
+func (h *hedge) samples() int {
+	if h.options.Percentile >= 1 {
+		return h.options.Window
+	}
+	return min(int(math.Round(1/(1-h.options.Percentile))), h.options.Window)
+}
+
+func (h *hedge) search(ctx context.Context, query string) ([]Result, error) {
+	if h.options.MaxAttempts == 0 {
+		return nil, errors.New("no replicas to search")
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel() // once we've got a winner, the others give up
+
+	type attempt struct {
+		response
+		replica int
+	}
+	res := make(chan attempt, h.options.MaxAttempts) // room for everyone, so nobody blocks after we return
+	starts := make([]time.Time, 0, h.options.MaxAttempts)
+	finished := make([]bool, h.options.MaxAttempts)
+	launched, inFlight := 0, 0
+	launch := func() {
+		i := launched
+		replica := h.replicas[i]
+		starts = append(starts, h.clock.Now())
+		launched++
+		inFlight++
+		go func() {
+			results, err := replica(ctx, query)
+			res <- attempt{response{results, err}, i}
+		}()
+	}
+
+	launch()
+	timer := h.clock.NewTimer(h.delay())
+	defer timer.Stop()
+	restart := func() {
+		if !timer.Stop() {
+			select {
+			case <-timer.C():
+			default:
+			}
+		}
+		timer.Reset(h.delay())
+	}
+
+	var errs []error
+	for {
+		var hedge <-chan time.Time // nil, and so never fires, once every replica is tried
+		if launched < h.options.MaxAttempts {
+			hedge = timer.C()
+		}
+		select {
+		case r := <-res:
+			inFlight--
+			finished[r.replica] = true
+			if r.err == nil {
+				// Record the winner, and how long everyone still running had been going
+				// when they're cancelled
+				now := h.clock.Now()
+				for i, start := range starts {
+					if i == r.replica || !finished[i] {
+						h.record(now.Sub(start))
+					}
+				}
+				return r.results, nil
+			}
+			errs = append(errs, r.err)
+			switch {
+			case launched < h.options.MaxAttempts:
+				// No point waiting out the delay, try the next one now
+				launch()
+				restart()
+			case inFlight == 0:
+				return nil, errors.Join(errs...)
+			}
+		case <-hedge:
+			launch()
+			timer.Reset(h.delay())
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
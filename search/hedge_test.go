@@ -0,0 +1,172 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/yashap/concurrency/schedule"
+)
+
+// counted wraps a Search, counting how many times it's called
+func counted(search Search, calls *atomic.Int64) Search {
+	return func(ctx context.Context, query string) ([]Result, error) {
+		calls.Add(1)
+		return search(ctx, query)
+	}
+}
+
+func TestHedgedFastReplica(t *testing.T) {
+	clock := schedule.NewFakeClock(time.Now())
+	var calls atomic.Int64
+	search := Hedged(clock, HedgeOptions{Delay: time.Second}, fixed("ok", nil), counted(fixed("backup", nil), &calls))
+
+	if results, err := search(context.Background(), "golang"); err != nil || title(results) != "ok" {
+		t.Errorf("Expected %q, but got %v and error %v", "ok", results, err)
+	}
+	if calls.Load() != 0 {
+		t.Errorf("Expected the backup not to be tried when the first replica is fast")
+	}
+}
+
+func TestHedgedSlowReplica(t *testing.T) {
+	clock := schedule.NewFakeClock(time.Now())
+	cancelled := make(chan struct{})
+	search := Hedged(clock, HedgeOptions{Delay: time.Second}, hanging(cancelled), fixed("backup", nil))
+
+	type searched struct {
+		results []Result
+		err     error
+	}
+	done := make(chan searched)
+	go func() {
+		results, err := search(context.Background(), "golang")
+		done <- searched{results, err}
+	}()
+	clock.BlockUntil(1)
+	clock.Advance(time.Second)
+	if s := <-done; s.err != nil || title(s.results) != "backup" {
+		t.Errorf("Expected %q, but got %v and error %v", "backup", s.results, s.err)
+	}
+	<-cancelled // the slow replica was cancelled, once the backup won
+}
+
+func TestHedgedFailedReplica(t *testing.T) {
+	clock := schedule.NewFakeClock(time.Now())
+	boom := errors.New("boom")
+
+	// A failure tries the next replica straight away, without waiting for the delay
+	search := Hedged(clock, HedgeOptions{Delay: time.Hour}, fixed("", boom), fixed("backup", nil))
+	if results, err := search(context.Background(), "golang"); err != nil || title(results) != "backup" {
+		t.Errorf("Expected %q, but got %v and error %v", "backup", results, err)
+	}
+
+	// Unless that's all the attempts we're allowed
+	var calls atomic.Int64
+	search = Hedged(clock, HedgeOptions{Delay: time.Hour, MaxAttempts: 1}, fixed("", boom), counted(fixed("backup", nil), &calls))
+	if _, err := search(context.Background(), "golang"); !errors.Is(err, boom) {
+		t.Errorf("Expected %v, but got %v", boom, err)
+	}
+	if calls.Load() != 0 {
+		t.Errorf("Expected only 1 attempt")
+	}
+
+	bang := errors.New("bang")
+	search = Hedged(clock, HedgeOptions{Delay: time.Hour}, fixed("", boom), fixed("", bang))
+	if _, err := search(context.Background(), "golang"); !errors.Is(err, boom) || !errors.Is(err, bang) {
+		t.Errorf("Expected both %v and %v, but got %v", boom, bang, err)
+	}
+}
+
+func TestHedgedRecordsCancelledReplicas(t *testing.T) {
+	clock := schedule.NewFakeClock(time.Now())
+	cancelled := make(chan struct{})
+	h := &hedge{
+		clock:    clock,
+		options:  HedgeOptions{Delay: time.Second, Percentile: 1, Window: 100, MaxAttempts: 2},
+		replicas: []Search{hanging(cancelled), fixed("backup", nil)},
+	}
+
+	done := make(chan struct{})
+	go func() {
+		h.search(context.Background(), "golang")
+		close(done)
+	}()
+	clock.BlockUntil(1)
+	clock.Advance(time.Second)
+	<-done
+	<-cancelled
+
+	// The backup won straight away, but the slow replica had been running for a second
+	// by then, and that should count too
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if !slices.Contains(h.latencies, time.Second) {
+		t.Errorf("Expected the cancelled replica's 1s to be recorded, got %v", h.latencies)
+	}
+}
+
+func TestHedgedTooFewLatencies(t *testing.T) {
+	clock := schedule.NewFakeClock(time.Now())
+	cancelled := make(chan struct{})
+	var calls atomic.Int64
+	// Fast the first time, then slow
+	flaky := func(ctx context.Context, query string) ([]Result, error) {
+		if calls.Add(1) == 1 {
+			return fixed("fast", nil)(ctx, query)
+		}
+		return hanging(cancelled)(ctx, query)
+	}
+	var backups atomic.Int64
+	h := &hedge{
+		clock:    clock,
+		options:  HedgeOptions{Delay: time.Second, Percentile: 0.95, Window: 100, MaxAttempts: 2},
+		replicas: []Search{flaky, counted(fixed("backup", nil), &backups)},
+	}
+
+	h.search(context.Background(), "golang")
+	if d := h.delay(); d != time.Second {
+		t.Errorf("Expected the fixed delay after a single latency, was %v", d)
+	}
+
+	// So the slow search waits out the fixed delay, before trying the backup
+	done := make(chan []Result)
+	go func() {
+		results, _ := h.search(context.Background(), "golang")
+		done <- results
+	}()
+	clock.BlockUntil(1)
+	if backups.Load() != 0 {
+		t.Errorf("Expected the backup not to be tried before the delay")
+	}
+	clock.Advance(time.Second)
+	if results := <-done; title(results) != "backup" {
+		t.Errorf("Expected %q, got %v", "backup", results)
+	}
+	<-cancelled
+}
+
+func TestHedgeDelay(t *testing.T) {
+	h := &hedge{options: HedgeOptions{Delay: time.Second, Percentile: 0.95, Window: 100}}
+	if d := h.delay(); d != time.Second {
+		t.Errorf("Expected the fixed delay before there are any latencies, was %v", d)
+	}
+
+	for i := 100; i >= 1; i-- {
+		h.record(time.Duration(i) * time.Millisecond)
+	}
+	if d := h.delay(); d != 95*time.Millisecond {
+		t.Errorf("Expected the p95 latency of 95ms, was %v", d)
+	}
+
+	// Only the most recent latencies count
+	for i := 0; i < 100; i++ {
+		h.record(10 * time.Millisecond)
+	}
+	if d := h.delay(); d != 10*time.Millisecond {
+		t.Errorf("Expected the p95 latency of 10ms, was %v", d)
+	}
+}
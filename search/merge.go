@@ -0,0 +1,92 @@
+package search
+
+import (
+	"slices"
+)
+
+// MergeStrategy combines several ranked lists of results, e.g. one per vertical, into
+// one ranked list
+type MergeStrategy func(lists [][]Result) []Result
+
+// RoundRobin interleaves the lists: the best result from each, then the second best
+// from each, and so on. Scores are ignored, so verticals that score differently still
+// get a fair share of the top spots
+func RoundRobin() MergeStrategy {
+	return func(lists [][]Result) []Result {
+		var merged []Result
+		for rank := 0; ; rank++ {
+			added := false
+			for _, list := range lists {
+				if rank < len(list) {
+					merged = append(merged, list[rank])
+					added = true
+				}
+			}
+			if !added {
+				return merged
+			}
+		}
+	}
+}
+
+// ByScore ranks every result by its Score, best first. It only makes sense if the
+// backends' scores are comparable with each other
+func ByScore() MergeStrategy {
+	return func(lists [][]Result) []Result {
+		merged := slices.Concat(lists...)
+		slices.SortStableFunc(merged, func(a, b Result) int {
+			switch {
+			case a.Score > b.Score:
+				return -1
+			case a.Score < b.Score:
+				return 1
+			}
+			return 0
+		})
+		return merged
+	}
+}
+
+// ReciprocalRankFusion ranks results by the sum of 1 / (k + rank) over every list they
+// appear in, where rank starts at 1. It only looks at ranks, not scores, so it works
+// when backends score differently, and results that several lists agree on rise to
+// the top. k dampens how much the very top ranks dominate, 60 is the usual choice.
+// Results are matched up by URL, and each one's Score is set to its fused score.
+// Results without a URL can't be matched up, so each stands on its own
+func ReciprocalRankFusion(k float64) MergeStrategy {
+	return func(lists [][]Result) []Result {
+		var merged []Result
+		index := make(map[string]int) // URL to position in merged
+		for _, list := range lists {
+			for rank, r := range list {
+				score := 1 / (k + float64(rank+1))
+				if i, ok := index[r.URL]; ok {
+					merged[i].Score += score
+					continue
+				}
+				if r.URL != "" {
+					index[r.URL] = len(merged)
+				}
+				r.Score = score
+				merged = append(merged, r)
+			}
+		}
+		return ByScore()([][]Result{merged})
+	}
+}
+
+// Merge combines several ranked lists of results into one with strategy, then removes
+// any duplicates by URL, keeping whichever ranked higher. Results without a URL are
+// never counted as duplicates
+func Merge(strategy MergeStrategy, lists ...[]Result) []Result {
+	seen := make(map[string]bool)
+	var merged []Result
+	for _, r := range strategy(lists) {
+		if r.URL != "" && seen[r.URL] {
+			continue
+		}
+		seen[r.URL] = true
+		merged = append(merged, r)
+	}
+	return merged
+}
@@ -0,0 +1,67 @@
+package search
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+// ranked returns results with the given URLs, scored in the given order
+func ranked(source string, scores map[string]float64, urls ...string) []Result {
+	results := make([]Result, len(urls))
+	for i, url := range urls {
+		results[i] = Result{URL: url, Source: source, Score: scores[url]}
+	}
+	return results
+}
+
+func urls(results []Result) []string {
+	var urls []string
+	for _, r := range results {
+		urls = append(urls, r.URL)
+	}
+	return urls
+}
+
+func TestMerge(t *testing.T) {
+	scores := map[string]float64{"a": 0.9, "b": 0.5, "c": 0.8, "d": 0.1, "e": 0.7}
+	web := ranked("web", scores, "a", "b", "d")
+	news := ranked("news", scores, "c", "e", "a")
+
+	tests := []struct {
+		name     string
+		strategy MergeStrategy
+		expected []string
+	}{
+		{"RoundRobin", RoundRobin(), []string{"a", "c", "b", "e", "d"}},
+		{"ByScore", ByScore(), []string{"a", "c", "e", "b", "d"}},
+		// a is in both lists, so it comes first, then the others by rank
+		{"ReciprocalRankFusion", ReciprocalRankFusion(60), []string{"a", "c", "b", "e", "d"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// Every strategy drops the second a
+			if merged := urls(Merge(test.strategy, web, news)); !reflect.DeepEqual(merged, test.expected) {
+				t.Errorf("Expected %v, got %v", test.expected, merged)
+			}
+		})
+	}
+}
+
+func TestMergeWithoutURLs(t *testing.T) {
+	web := []Result{{Title: "a"}, {Title: "b"}}
+	news := []Result{{Title: "c"}}
+	for _, strategy := range []MergeStrategy{RoundRobin(), ByScore(), ReciprocalRankFusion(60)} {
+		if merged := Merge(strategy, web, news); len(merged) != 3 {
+			t.Errorf("Expected all 3 results without URLs to be kept, got %+v", merged)
+		}
+	}
+}
+
+func TestReciprocalRankFusionScores(t *testing.T) {
+	merged := Merge(ReciprocalRankFusion(60), ranked("web", nil, "a", "b"), ranked("news", nil, "b"))
+	// b is 2nd in one list and 1st in the other
+	if expected := 1.0/62 + 1.0/61; merged[0].URL != "b" || math.Abs(merged[0].Score-expected) > 1e-12 {
+		t.Errorf("Expected b first with score %v, got %+v", expected, merged[0])
+	}
+}
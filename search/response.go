@@ -0,0 +1,133 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/yashap/concurrency/future"
+)
+
+// Status is how a vertical's search went
+type Status int
+
+const (
+	// StatusOK means the vertical returned a result
+	StatusOK Status = iota
+	// StatusTimedOut means ctx's deadline passed before the vertical returned
+	StatusTimedOut
+	// StatusFailed means the vertical returned an error, or ctx was cancelled
+	StatusFailed
+)
+
+func (s Status) String() string {
+	switch s {
+	case StatusOK:
+		return "ok"
+	case StatusTimedOut:
+		return "timed out"
+	case StatusFailed:
+		return "failed"
+	}
+	return "unknown"
+}
+
+// VerticalResponse is how one vertical's search went
+type VerticalResponse struct {
+	Vertical string
+	Results  []Result // only set if Status is StatusOK
+	Status   Status
+	Err      error
+	Latency  time.Duration // how long it took to return, or to give up on it
+}
+
+// SearchResponse is how a search of every vertical went, see SearchPartial
+type SearchResponse struct {
+	Verticals []VerticalResponse // in the order the verticals were registered
+	Complete  bool               // whether every vertical returned a result
+}
+
+// Merge merges the results of the verticals that returned some into one list, see
+// the package's Merge
+func (r SearchResponse) Merge(strategy MergeStrategy) []Result {
+	var lists [][]Result
+	for _, v := range r.Verticals {
+		if v.Status == StatusOK {
+			lists = append(lists, v.Results)
+		}
+	}
+	return Merge(strategy, lists...)
+}
+
+// SearchPartial searches every vertical concurrently, like Search, but rather than
+// failing if one does, it returns whatever results arrived before ctx's deadline,
+// and how each vertical went. It never waits past ctx being done, even for backends
+// that ignore ctx, and results that arrived before then are always reported, however
+// long an earlier vertical kept it waiting
+func (s *Searcher) SearchPartial(ctx context.Context, query string) SearchResponse {
+	return s.collectPartial(ctx, s.startPartial(ctx, query))
+}
+
+// timed is a vertical's results, and how long they took
+type timed struct {
+	results []Result
+	latency time.Duration
+}
+
+// partial is a SearchPartial that's been started, but not collected
+type partial struct {
+	start     time.Time
+	verticals []string
+	futures   []*future.Future[timed] // one per vertical
+}
+
+// startPartial starts searching every vertical, for SearchPartial
+func (s *Searcher) startPartial(ctx context.Context, query string) partial {
+	p := partial{start: s.clock.Now()}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	p.verticals = append([]string(nil), s.verticals...)
+	p.futures = make([]*future.Future[timed], len(p.verticals))
+	for i, vertical := range p.verticals {
+		p.futures[i] = future.Go(ctx, s.timed(s.searches[vertical], query, p.start))
+	}
+	return p
+}
+
+// timed wraps a search, so it also says how long it took since start
+func (s *Searcher) timed(search Search, query string, start time.Time) func(ctx context.Context) (timed, error) {
+	return func(ctx context.Context) (timed, error) {
+		results, err := search(ctx, query)
+		return timed{results, s.clock.Now().Sub(start)}, err
+	}
+}
+
+// collectPartial waits for the verticals' results, until ctx is done, for SearchPartial
+func (s *Searcher) collectPartial(ctx context.Context, p partial) SearchResponse {
+	response := SearchResponse{Complete: true}
+	for i, f := range p.futures {
+		f.Await(ctx) // until it's ready, or ctx is done
+		v := VerticalResponse{Vertical: p.verticals[i]}
+		var results []Result
+		select {
+		case <-f.Done():
+			t, err := f.Await(ctx) // it's ready, so this doesn't block
+			results, v.Err, v.Latency = t.results, err, t.latency
+		default:
+			v.Err, v.Latency = ctx.Err(), s.clock.Now().Sub(p.start) // we gave up waiting
+		}
+		switch {
+		case v.Err == nil:
+			v.Status, v.Results = StatusOK, results
+		case errors.Is(v.Err, context.DeadlineExceeded):
+			v.Status = StatusTimedOut
+		default:
+			v.Status = StatusFailed
+		}
+		if v.Status != StatusOK {
+			response.Complete = false
+		}
+		response.Verticals = append(response.Verticals, v)
+	}
+	return response
+}
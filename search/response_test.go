@@ -0,0 +1,86 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/yashap/concurrency/schedule"
+)
+
+// expired returns a ctx that's already past its deadline, to collect a partial search
+// with, as if its deadline had passed once the fast verticals had returned
+func expired(ctx context.Context) (context.Context, context.CancelFunc) {
+	return context.WithDeadline(ctx, time.Now())
+}
+
+func TestSearchPartial(t *testing.T) {
+	clock := schedule.NewFakeClock(time.Now())
+	s := NewSearcher(clock, nil)
+	boom := errors.New("boom")
+	cancelled := make(chan struct{})
+	s.Register("web", fixed("web", nil))
+	s.Register("image", fixed("", boom))
+	s.Register("video", hanging(cancelled))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	p := s.startPartial(ctx, "golang")
+	<-p.futures[0].Done()
+	<-p.futures[1].Done()
+	clock.Advance(10 * time.Millisecond)
+	deadline, cancelDeadline := expired(ctx)
+	defer cancelDeadline()
+	response := s.collectPartial(deadline, p)
+	cancel()
+	<-cancelled // the hanging vertical isn't left running
+
+	if response.Complete {
+		t.Errorf("Expected the response not to be complete")
+	}
+	statuses := map[string]Status{}
+	for _, v := range response.Verticals {
+		statuses[v.Vertical] = v.Status
+	}
+	expected := map[string]Status{"web": StatusOK, "image": StatusFailed, "video": StatusTimedOut}
+	if !reflect.DeepEqual(statuses, expected) {
+		t.Errorf("Expected %v, got %v", expected, statuses)
+	}
+	if v := response.Verticals[1]; !errors.Is(v.Err, boom) {
+		t.Errorf("Expected the image vertical to fail with %v, but got %v", boom, v.Err)
+	}
+	if v := response.Verticals[0]; v.Latency != 0 {
+		t.Errorf("Expected the web vertical to take no time, took %v", v.Latency)
+	}
+	if v := response.Verticals[2]; v.Latency != 10*time.Millisecond {
+		t.Errorf("Expected the video vertical to take until the deadline, 10ms, took %v", v.Latency)
+	}
+	if results := response.Merge(RoundRobin()); len(results) != 1 || title(results) != "web" {
+		t.Errorf("Expected only the web result, got %v", results)
+	}
+}
+
+func TestSearchPartialKeepsArrivedResults(t *testing.T) {
+	s := NewSearcher(schedule.NewFakeClock(time.Now()), nil)
+	s.Register("video", hanging(make(chan struct{})))
+	s.Register("web", fixed("web", nil)) // arrives while we wait on video
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	p := s.startPartial(ctx, "golang")
+	<-p.futures[1].Done()
+	deadline, cancelDeadline := expired(ctx)
+	defer cancelDeadline()
+	response := s.collectPartial(deadline, p)
+	if v := response.Verticals[1]; v.Status != StatusOK {
+		t.Errorf("Expected the web vertical to be ok, was %v, with error %v", v.Status, v.Err)
+	}
+}
+
+func TestSearchPartialComplete(t *testing.T) {
+	response := GoogleWithReplicasAndTimeout(context.Background(), "golang")
+	if !response.Complete || len(response.Merge(RoundRobin())) != 9 {
+		t.Errorf("Expected a complete response with 9 results, got %+v", response)
+	}
+}
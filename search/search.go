@@ -1,117 +1,167 @@
 package search
 
 import (
+	"context"
 	"fmt"
 	"math/rand"
+	"net/url"
 	"time"
+
+	"github.com/yashap/concurrency/future"
+	"github.com/yashap/concurrency/schedule"
 )
 
 // Result represents a search result
-type Result string
+type Result struct {
+	Title   string
+	URL     string
+	Snippet string
+	Score   float64 // how relevant the backend thinks it is, higher is better
+	Source  string  // which backend it came from
+}
 
-// Search is a function that, given a query, can be used to execute a search
-type Search func(query string) Result
+// Search is a function that, given a query, can be used to execute a search. It returns
+// results ranked best first. It should give up, and return ctx.Err(), once ctx is done
+type Search func(ctx context.Context, query string) ([]Result, error)
 
-func fakeSearch(kind string) Search {
-	return func(query string) Result {
-		time.Sleep(time.Duration(rand.Intn(100)) * time.Millisecond)
-		return Result(fmt.Sprintf("%s result for %q\n", kind, query))
+// Fake returns a Search that pretends to search, taking up to 100ms, and returns a few
+// results saying which backend they came from
+func Fake(name string) Search {
+	return func(ctx context.Context, query string) ([]Result, error) {
+		select {
+		case <-time.After(time.Duration(rand.Intn(100)) * time.Millisecond):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		results := make([]Result, 3)
+		for i := range results {
+			results[i] = Result{
+				Title:   fmt.Sprintf("%s result %d for %q", name, i+1, query),
+				URL:     fmt.Sprintf("https://%s.example.com/%s/%d", name, url.PathEscape(query), i+1),
+				Snippet: fmt.Sprintf("Something about %s", query),
+				Score:   1 / float64(i+1),
+				Source:  name,
+			}
+		}
+		return results, nil
 	}
 }
 
+// The talk's examples search these fake web, image and video backends
 var (
-	web1   = fakeSearch("web1")
-	web2   = fakeSearch("web2")
-	web3   = fakeSearch("web3")
-	image1 = fakeSearch("image1")
-	image2 = fakeSearch("image2")
-	image3 = fakeSearch("image3")
-	video1 = fakeSearch("video1")
-	video2 = fakeSearch("video2")
-	video3 = fakeSearch("video3")
+	demoClock  = schedule.NewRealClock()
+	demoSingle = NewFakeSearcher(demoClock, nil, 1)
+	demo       = NewFakeSearcher(demoClock, Replicas, 3)
+	demoHedged = NewFakeSearcher(demoClock, func(replicas ...Search) Search {
+		return Hedged(demoClock, HedgeOptions{Delay: 50 * time.Millisecond, Percentile: 0.95}, replicas...)
+	}, 3)
 )
 
+// primaries returns the single replica of each of the demo's verticals
+func primaries() []Search {
+	var searches []Search
+	for _, vertical := range demoSingle.Verticals() {
+		searches = append(searches, demoSingle.backend(vertical, 0))
+	}
+	return searches
+}
+
 // GoogleSynchronous is a function that, given a query, pretends to search for matching
 // websites, images and videos. It performs the searches synchronously
-func GoogleSynchronous(query string) (results []Result) {
-	return []Result{web1(query), image1(query), video1(query)}
+func GoogleSynchronous(ctx context.Context, query string) (results []Result, err error) {
+	for _, search := range primaries() {
+		found, err := search(ctx, query)
+		if err != nil {
+			return results, err
+		}
+		results = append(results, found...)
+	}
+	return results, nil
 }
 
-// Google is a function that, given a query, pretends to search for matching websites,
-// images and videos. It performs the searches concurrently
-func Google(query string) (results []Result) {
-	res := make(chan Result)
-	go func() { res <- web1(query) }()
-	go func() { res <- image1(query) }()
-	go func() { res <- video1(query) }()
-
-	for i := 0; i < 3; i++ {
-		results = append(results, <-res)
-	}
-	return
+type response struct {
+	results []Result
+	err     error
 }
 
-// GoogleWithTimeout is like Google, but with a timeout
-func GoogleWithTimeout(query string) (results []Result) {
-	res := make(chan Result)
-	go func() { res <- web1(query) }()
-	go func() { res <- image1(query) }()
-	go func() { res <- video1(query) }()
+// Google is a function that, given a query, pretends to search for matching websites,
+// images and videos. It performs the searches concurrently. If one fails, or ctx is
+// done, it returns what it has so far, and the error
+func Google(ctx context.Context, query string) (results []Result, err error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel() // if we return early, the other searches give up
+	searches := primaries()
+	res := make(chan response, len(searches)) // room for everyone, so nobody blocks if we return early
+	for _, search := range searches {
+		go func(search Search) {
+			found, err := search(ctx, query)
+			res <- response{found, err}
+		}(search)
+	}
 
-	timeout := time.After(80 * time.Millisecond)
-	for i := 0; i < 3; i++ {
+	for range searches {
 		select {
-		case result := <-res:
-			results = append(results, result)
-		case <-timeout:
-			fmt.Println("timed out")
-			return
+		case r := <-res:
+			if r.err != nil {
+				return results, r.err
+			}
+			results = append(results, r.results...)
+		case <-ctx.Done():
+			return results, ctx.Err()
 		}
 	}
-	return
+	return results, nil
 }
 
-// First takes a query and a set of Search services, returns the first one that
-// comes back
-func First(query string, replicas ...Search) Result {
-	c := make(chan Result)
-	searchReplica := func(i int) { c <- replicas[i](query) }
-	for i := range replicas {
-		go searchReplica(i)
+// GoogleWithTimeout is like Google, but rather than failing if a search is still
+// going at ctx's deadline, it returns what it has, and which searches are missing,
+// e.g.
+//
+//	ctx, cancel := context.WithTimeout(ctx, 80*time.Millisecond)
+//	defer cancel()
+//	response := GoogleWithTimeout(ctx, "golang")
+func GoogleWithTimeout(ctx context.Context, query string) SearchResponse {
+	return demoSingle.SearchPartial(ctx, query)
+}
+
+// First takes a query and a set of Search services, and returns the first successful
+// result. The other replicas are cancelled, so they don't keep working, or block,
+// once there's a winner. If they all fail, it returns all of their errors
+func First(ctx context.Context, query string, replicas ...Search) ([]Result, error) {
+	futures := make([]*future.Future[[]Result], len(replicas))
+	for i, replica := range replicas {
+		futures[i] = goSearch(ctx, query, replica)
 	}
-	return <-c // return only the first to come back
+	return future.Any(futures...).Await(ctx)
+}
+
+// Replicas combines a set of Search services into one, which searches them all, and
+// returns the first successful result, see First
+func Replicas(replicas ...Search) Search {
+	return func(ctx context.Context, query string) ([]Result, error) {
+		return First(ctx, query, replicas...)
+	}
+}
+
+// goSearch runs a search in a Future
+func goSearch(ctx context.Context, query string, search Search) *future.Future[[]Result] {
+	return future.Go(ctx, func(ctx context.Context) ([]Result, error) { return search(ctx, query) })
 }
 
 // GoogleWithReplicas is like Google, but has a replica of each search service, takes
 // first result for each (for improved performance)
-func GoogleWithReplicas(query string) (results []Result) {
-	res := make(chan Result)
-	go func() { res <- First(query, web1, web2, web3) }()
-	go func() { res <- First(query, image1, image2, image3) }()
-	go func() { res <- First(query, video1, video2, video3) }()
-
-	for i := 0; i < 3; i++ {
-		results = append(results, <-res)
-	}
-	return
+func GoogleWithReplicas(ctx context.Context, query string) (results []Result, err error) {
+	return demo.merge(RoundRobin())(demo.Search(ctx, query))
 }
 
 // GoogleWithReplicasAndTimeout is a combo of GoogleWithReplicas and GoogleWithTimeout
-func GoogleWithReplicasAndTimeout(query string) (results []Result) {
-	res := make(chan Result)
-	go func() { res <- First(query, web1, web2, web3) }()
-	go func() { res <- First(query, image1, image2, image3) }()
-	go func() { res <- First(query, video1, video2, video3) }()
-
-	timeout := time.After(80 * time.Millisecond)
-	for i := 0; i < 3; i++ {
-		select {
-		case result := <-res:
-			results = append(results, result)
-		case <-timeout:
-			fmt.Println("timed out")
-			return
-		}
-	}
-	return
+func GoogleWithReplicasAndTimeout(ctx context.Context, query string) SearchResponse {
+	return demo.SearchPartial(ctx, query)
+}
+
+// GoogleWithHedging is like GoogleWithReplicas, but only tries another replica if the
+// first is slower than usual, so most searches only hit one replica of each service,
+// rather than all three
+func GoogleWithHedging(ctx context.Context, query string) (results []Result, err error) {
+	return demoHedged.merge(RoundRobin())(demoHedged.Search(ctx, query))
 }
@@ -0,0 +1,63 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// fixed returns a Search that returns a result with the given title, or err if it's
+// not nil
+func fixed(title string, err error) Search {
+	return func(context.Context, string) ([]Result, error) {
+		if err != nil {
+			return nil, err
+		}
+		return []Result{{Title: title, URL: "https://example.com/" + title}}, nil
+	}
+}
+
+// title returns the title of the first result, if there is one
+func title(results []Result) string {
+	if len(results) == 0 {
+		return ""
+	}
+	return results[0].Title
+}
+
+// hanging returns a Search that never finishes, until ctx is done, and then says so
+func hanging(cancelled chan<- struct{}) Search {
+	return func(ctx context.Context, _ string) ([]Result, error) {
+		<-ctx.Done()
+		close(cancelled)
+		return nil, ctx.Err()
+	}
+}
+
+func TestFirst(t *testing.T) {
+	ctx := context.Background()
+	boom := errors.New("boom")
+	cancelled := make(chan struct{})
+	result, err := First(ctx, "golang", fixed("", boom), hanging(cancelled), fixed("ok", nil))
+	if err != nil || title(result) != "ok" {
+		t.Errorf("Expected the first success %q, but got %v and error %v", "ok", result, err)
+	}
+	<-cancelled // the loser was cancelled, rather than left running
+}
+
+func TestFirstAllFail(t *testing.T) {
+	boom := errors.New("boom")
+	bang := errors.New("bang")
+	_, err := First(context.Background(), "golang", fixed("", boom), fixed("", bang))
+	if !errors.Is(err, boom) || !errors.Is(err, bang) {
+		t.Errorf("Expected both %v and %v, but got %v", boom, bang, err)
+	}
+}
+
+func TestGoogleWithReplicas(t *testing.T) {
+	results, err := GoogleWithReplicas(context.Background(), "golang")
+	// 3 from each of web, image and video
+	if err != nil || len(results) != 9 {
+		t.Errorf("Expected 9 results, but got %v and error %v", results, err)
+	}
+}
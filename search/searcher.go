@@ -0,0 +1,114 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/yashap/concurrency/future"
+	"github.com/yashap/concurrency/schedule"
+)
+
+// Searcher searches a set of verticals, e.g. web, image and video, each backed by one
+// or more replicas of a search service. Backends, real or fake, can be registered at
+// any time, and it's safe for concurrent use
+type Searcher struct {
+	clock   schedule.Clock
+	combine func(replicas ...Search) Search
+
+	mu        sync.RWMutex
+	verticals []string // in the order they were registered
+	replicas  map[string][]Search
+	searches  map[string]Search // each vertical's replicas, combined
+}
+
+// NewSearcher creates an empty Searcher. Each vertical's replicas are combined into
+// one Search with combine, e.g. Replicas, or a Hedged search, which is also what's
+// used if combine is nil. Latencies are measured with clock
+func NewSearcher(clock schedule.Clock, combine func(replicas ...Search) Search) *Searcher {
+	if combine == nil {
+		combine = Replicas
+	}
+	return &Searcher{
+		clock:    clock,
+		combine:  combine,
+		replicas: make(map[string][]Search),
+		searches: make(map[string]Search),
+	}
+}
+
+// Register adds replicas to a vertical, adding the vertical if it's new
+func (s *Searcher) Register(vertical string, replicas ...Search) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.replicas[vertical]; !ok {
+		s.verticals = append(s.verticals, vertical)
+	}
+	s.replicas[vertical] = append(s.replicas[vertical], replicas...)
+	s.searches[vertical] = s.combine(s.replicas[vertical]...)
+}
+
+// Verticals returns the registered verticals, in the order they were registered
+func (s *Searcher) Verticals() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]string(nil), s.verticals...)
+}
+
+// backend returns one of a vertical's replicas, for the talk's examples
+func (s *Searcher) backend(vertical string, replica int) Search {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.replicas[vertical][replica]
+}
+
+// Search searches every vertical concurrently, and returns each one's results, keyed
+// by vertical. If any vertical fails, the others are cancelled, and it returns the
+// error
+func (s *Searcher) Search(ctx context.Context, query string) (map[string][]Result, error) {
+	s.mu.RLock()
+	verticals := append([]string(nil), s.verticals...)
+	futures := make([]*future.Future[[]Result], len(verticals))
+	for i, vertical := range verticals {
+		futures[i] = goSearch(ctx, query, s.searches[vertical])
+	}
+	s.mu.RUnlock()
+
+	results, err := future.All(futures...).Await(ctx)
+	if err != nil {
+		return nil, err
+	}
+	byVertical := make(map[string][]Result, len(verticals))
+	for i, vertical := range verticals {
+		byVertical[vertical] = results[i]
+	}
+	return byVertical, nil
+}
+
+// merge merges the Searcher's results into one list, taking the verticals in the order
+// they were registered, see Merge
+func (s *Searcher) merge(strategy MergeStrategy) func(byVertical map[string][]Result, err error) ([]Result, error) {
+	return func(byVertical map[string][]Result, err error) ([]Result, error) {
+		if err != nil {
+			return nil, err
+		}
+		var lists [][]Result
+		for _, vertical := range s.Verticals() {
+			lists = append(lists, byVertical[vertical])
+		}
+		return Merge(strategy, lists...), nil
+	}
+}
+
+// NewFakeSearcher creates a Searcher like the talk's, with web, image and video
+// verticals, each backed by the given number of Fake replicas, named e.g. web1, web2.
+// Replicas are combined with combine, see NewSearcher
+func NewFakeSearcher(clock schedule.Clock, combine func(replicas ...Search) Search, replicas int) *Searcher {
+	s := NewSearcher(clock, combine)
+	for _, vertical := range []string{"web", "image", "video"} {
+		for i := 1; i <= replicas; i++ {
+			s.Register(vertical, Fake(fmt.Sprintf("%s%d", vertical, i)))
+		}
+	}
+	return s
+}
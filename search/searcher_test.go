@@ -0,0 +1,52 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/yashap/concurrency/schedule"
+)
+
+func TestSearcher(t *testing.T) {
+	s := NewSearcher(schedule.NewFakeClock(time.Now()), nil)
+	boom := errors.New("boom")
+	s.Register("web", fixed("", boom), fixed("web", nil))
+	s.Register("news", fixed("news", nil))
+
+	if verticals := s.Verticals(); !reflect.DeepEqual(verticals, []string{"web", "news"}) {
+		t.Errorf("Expected verticals in the order they were registered, got %v", verticals)
+	}
+	results, err := s.Search(context.Background(), "golang")
+	if err != nil || title(results["web"]) != "web" || title(results["news"]) != "news" {
+		t.Errorf("Expected web and news results, but got %v and error %v", results, err)
+	}
+}
+
+func TestSearcherCombine(t *testing.T) {
+	// Only ever search the last replica
+	last := func(replicas ...Search) Search { return replicas[len(replicas)-1] }
+	s := NewSearcher(schedule.NewFakeClock(time.Now()), last)
+	s.Register("web", fixed("first", nil))
+	s.Register("web", fixed("second", nil)) // adds another replica
+
+	results, err := s.Search(context.Background(), "golang")
+	if err != nil || title(results["web"]) != "second" {
+		t.Errorf("Expected %q, but got %v and error %v", "second", results, err)
+	}
+}
+
+func TestSearcherFails(t *testing.T) {
+	s := NewSearcher(schedule.NewFakeClock(time.Now()), nil)
+	boom := errors.New("boom")
+	cancelled := make(chan struct{})
+	s.Register("web", fixed("", boom))
+	s.Register("image", hanging(cancelled))
+
+	if _, err := s.Search(context.Background(), "golang"); !errors.Is(err, boom) {
+		t.Errorf("Expected %v, but got %v", boom, err)
+	}
+	<-cancelled // the other vertical was cancelled
+}